@@ -0,0 +1,78 @@
+package core
+
+import (
+	"fmt"
+
+	"github.com/aethiopicuschan/cubism-go/core/drawable"
+	"github.com/aethiopicuschan/cubism-go/core/moc"
+	"github.com/aethiopicuschan/cubism-go/core/parameter"
+	core_5_0_0 "github.com/aethiopicuschan/cubism-go/internal/core/core_5_0_0"
+	"github.com/aethiopicuschan/cubism-go/internal/core/minimum"
+)
+
+// Interface to the Cubism Core
+// The uintptr arguments are the model pointers held by [moc.Moc]
+// The method set is kept as is, and the later capabilities are optional interfaces such as [PartReader]
+type Core interface {
+	LoadMoc(path string) (moc.Moc, error)
+	GetVersion() string
+	GetDynamicFlags(uintptr) []drawable.DynamicFlag
+	// Get the opacities of the drawables without copying
+	// The slice refers to the memory of the core, which Update overwrites
+	GetOpacities(uintptr) []float32
+	// Get the vertex positions of the drawables without copying
+	// The slices refer to the memory of the core, which Update overwrites
+	GetVertexPositions(uintptr) [][]drawable.Vector2
+	// Get the drawables
+	// All the data is copied, so it stays valid after Update
+	GetDrawables(uintptr) []drawable.Drawable
+	GetParameters(uintptr) []parameter.Parameter
+	GetParameterValue(uintptr, string) float32
+	SetParameterValue(uintptr, string, float32)
+	GetPartIds(uintptr) []string
+	SetPartOpacity(uintptr, string, float32)
+	GetSortedDrawableIndices(uintptr) []int
+	GetCanvasInfo(uintptr) (drawable.Vector2, drawable.Vector2, float32)
+	Update(uintptr)
+}
+
+// The implementation for the library, with all the optional capabilities
+type fullCore interface {
+	Core
+	MocVersioner
+	LogSetter
+	PartReader
+	DynamicFlagBitsReader
+	RenderOrderReader
+	MocReleaser
+	Closer
+}
+
+var _ fullCore = (*core_5_0_0.Core)(nil)
+
+// Load the dynamic library and return the implementation matching its version
+func NewCore(lib string) (c Core, err error) {
+	l, err := openLibrary(lib)
+	if err != nil {
+		return
+	}
+	mc, err := minimum.NewCore(l)
+	if err != nil {
+		closeLibrary(l)
+		return
+	}
+	version := mc.GetVersion()
+	if version == "5.0.0" {
+		// Assigned only on success, so that a failure does not return a nil *core_5_0_0.Core as a non-nil Core
+		var cc *core_5_0_0.Core
+		if cc, err = core_5_0_0.NewCore(l); err != nil {
+			closeLibrary(l)
+			return
+		}
+		c = cc
+		return
+	}
+	err = fmt.Errorf("unsupported version: %s", version)
+	closeLibrary(l)
+	return
+}
@@ -0,0 +1,13 @@
+/*
+Package core defines the interface to the Cubism Core and the types exchanged through it.
+
+The [Core] interface and the types in the subpackages are the stable surface of cubism-go.
+The capabilities added after [Core] are optional interfaces, such as [PartReader] or [Closer],
+which are checked by type assertion like sound.Releaser, so the existing implementations keep working.
+The functions of this package taking a [Core], such as [GetParts], use them and fall back to the methods of [Core].
+
+The implementations for each version of the native library live in internal packages,
+and [NewCore] selects one of them based on the version reported by the library.
+This allows new versions of the Cubism Core to be supported without changing this package.
+*/
+package core
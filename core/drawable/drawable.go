@@ -10,4 +10,6 @@ type Drawable struct {
 	DynamicFlag     DynamicFlag
 	Opacity         float32
 	Masks           []int32
+	// Index of the parent part, -1 if the drawable is at the root
+	Parent int
 }
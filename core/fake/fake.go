@@ -0,0 +1,425 @@
+/*
+Package fake provides an in-memory implementation of [core.Core].
+
+It does not require the native Cubism Core, so code built on top of cubism-go can be tested anywhere.
+Each call to [Core.LoadMoc] instantiates the [Model] given to [NewCore],
+and [Core.Update] deforms it deterministically according to the parameter values.
+[WriteFixture] writes a set of model files matching [FixtureModel].
+*/
+package fake
+
+import (
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/aethiopicuschan/cubism-go/core"
+	"github.com/aethiopicuschan/cubism-go/core/drawable"
+	"github.com/aethiopicuschan/cubism-go/core/moc"
+	"github.com/aethiopicuschan/cubism-go/core/parameter"
+	"github.com/aethiopicuschan/cubism-go/core/part"
+)
+
+// Version reported by [Core.GetVersion]
+const Version = "5.0.0"
+
+// The latest moc3 version reported by [Core.GetLatestMocVersion]
+const LatestMocVersion = moc.Version50
+
+// Bits of the dynamic flags
+const (
+	flagIsVisible                = 1
+	flagVisibilityDidChange      = 2
+	flagOpacityDidChange         = 4
+	flagDrawOrderDidChange       = 8
+	flagRenderOrderDidChange     = 16
+	flagVertexPositionsDidChange = 32
+)
+
+var (
+	_ core.Core                  = (*Core)(nil)
+	_ core.MocVersioner          = (*Core)(nil)
+	_ core.LogSetter             = (*Core)(nil)
+	_ core.PartReader            = (*Core)(nil)
+	_ core.DynamicFlagBitsReader = (*Core)(nil)
+	_ core.RenderOrderReader     = (*Core)(nil)
+	_ core.MocReleaser           = (*Core)(nil)
+	_ core.Closer                = (*Core)(nil)
+)
+
+// In-memory implementation of [core.Core]
+type Core struct {
+	mu          sync.Mutex
+	model       Model
+	instances   map[uintptr]*instance
+	lastPtr     uintptr
+	logFunction func(string)
+}
+
+// State of a loaded model
+type instance struct {
+	model         *Model
+	values        []float32
+	partOpacities []float32
+	positions     [][]drawable.Vector2
+	opacities     []float32
+	flags         []uint8
+	sortedIndices []int
+	renderOrders  []int32
+	updated       bool
+}
+
+// Constructor for the [Core] struct
+func NewCore(m Model) *Core {
+	return &Core{
+		model:     m,
+		instances: make(map[uintptr]*instance),
+	}
+}
+
+func (c *Core) get(modelPtr uintptr) *instance {
+	inst, ok := c.instances[modelPtr]
+	if !ok {
+		panic(fmt.Sprintf("fake: unknown model pointer: %d", modelPtr))
+	}
+	return inst
+}
+
+// Load moc3 and return moc.Moc
+// Only the header of the file is checked, and the rest of the content is ignored
+func (c *Core) LoadMoc(path string) (m moc.Moc, err error) {
+	m.MocBuffer, err = os.ReadFile(path)
+	if err != nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	m.Version, err = moc.ReadVersion(m.MocBuffer)
+	if err != nil {
+		c.log("[CSM] [E]csmHasMocConsistency: File is not a moc3 file.")
+		return
+	}
+	if m.Version > LatestMocVersion {
+		err = &moc.ErrMocTooNew{
+			Version: m.Version,
+			Latest:  LatestMocVersion,
+		}
+		return
+	}
+	inst := &instance{
+		model:         &c.model,
+		values:        make([]float32, len(c.model.Parameters)),
+		partOpacities: make([]float32, len(c.model.Parts)),
+		positions:     make([][]drawable.Vector2, len(c.model.Drawables)),
+		opacities:     make([]float32, len(c.model.Drawables)),
+		flags:         make([]uint8, len(c.model.Drawables)),
+		sortedIndices: make([]int, len(c.model.Drawables)),
+		renderOrders:  make([]int32, len(c.model.Drawables)),
+	}
+	for i, p := range c.model.Parameters {
+		inst.values[i] = p.Default
+	}
+	for i, p := range c.model.Parts {
+		inst.partOpacities[i] = p.Opacity
+	}
+	for i, d := range c.model.Drawables {
+		inst.positions[i] = make([]drawable.Vector2, len(d.VertexPositions))
+		inst.sortedIndices[i] = i
+		inst.renderOrders[i] = int32(i)
+	}
+	inst.deform()
+	c.lastPtr++
+	c.instances[c.lastPtr] = inst
+	m.MocPtr = c.lastPtr
+	m.ModelPtr = c.lastPtr
+	return
+}
+
+// Release the model
+// Using its pointer afterwards panics
+func (c *Core) ReleaseMoc(m moc.Moc) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.instances, m.ModelPtr)
+}
+
+// Close the core
+// All the models are released
+func (c *Core) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.instances = make(map[uintptr]*instance)
+	c.logFunction = nil
+	return nil
+}
+
+// Set the function receiving the log messages of the core
+func (c *Core) SetLogFunction(f func(string)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.logFunction = f
+}
+
+func (c *Core) log(message string) {
+	if c.logFunction != nil {
+		c.logFunction(message)
+	}
+}
+
+// Get the latest moc3 version supported by the core
+func (c *Core) GetLatestMocVersion() moc.Version {
+	return LatestMocVersion
+}
+
+// Get the version of moc3
+// Unlike the native core, newer versions are returned as is
+func (c *Core) GetMocVersion(buf []byte) moc.Version {
+	v, _ := moc.ReadVersion(buf)
+	return v
+}
+
+// Get version
+func (c *Core) GetVersion() string {
+	return Version
+}
+
+// Get dynamic flags
+func (c *Core) GetDynamicFlags(modelPtr uintptr) (rs []drawable.DynamicFlag) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, flag := range c.get(modelPtr).flags {
+		rs = append(rs, drawable.ParseDynamicFlag(flag))
+	}
+	return
+}
+
+// Get the packed dynamic flags
+// Like the native core, the returned slice is overwritten by Update
+func (c *Core) GetDynamicFlagBits(modelPtr uintptr) []uint8 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.get(modelPtr).flags
+}
+
+// Get opacities
+// Like the native core, the returned slice is overwritten by Update
+func (c *Core) GetOpacities(modelPtr uintptr) []float32 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.get(modelPtr).opacities
+}
+
+// Get vertex positions
+// Like the native core, the returned slices are overwritten by Update
+func (c *Core) GetVertexPositions(modelPtr uintptr) [][]drawable.Vector2 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.get(modelPtr).positions
+}
+
+// Get Drawables
+func (c *Core) GetDrawables(modelPtr uintptr) (ds []drawable.Drawable) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	inst := c.get(modelPtr)
+	for i, d := range inst.model.Drawables {
+		ds = append(ds, drawable.Drawable{
+			Id:              d.Id,
+			Texture:         d.Texture,
+			VertexPositions: append([]drawable.Vector2(nil), inst.positions[i]...),
+			VertexUvs:       append([]drawable.Vector2(nil), d.VertexUvs...),
+			VertexIndices:   append([]uint16(nil), d.VertexIndices...),
+			ConstantFlag:    drawable.ParseConstantFlag(d.ConstantFlag),
+			DynamicFlag:     drawable.ParseDynamicFlag(inst.flags[i]),
+			Opacity:         inst.opacities[i],
+			Masks:           append([]int32(nil), d.Masks...),
+			Parent:          d.Parent,
+		})
+	}
+	return
+}
+
+// Get parameters
+func (c *Core) GetParameters(modelPtr uintptr) (parameters []parameter.Parameter) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	inst := c.get(modelPtr)
+	for i, p := range inst.model.Parameters {
+		p.Current = inst.values[i]
+		p.KeyValues = append([]float32(nil), p.KeyValues...)
+		parameters = append(parameters, p)
+	}
+	return
+}
+
+// Get parameter value
+func (c *Core) GetParameterValue(modelPtr uintptr, id string) float32 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	inst := c.get(modelPtr)
+	for i, p := range inst.model.Parameters {
+		if p.Id == id {
+			return inst.values[i]
+		}
+	}
+	return 0
+}
+
+// Set parameter value
+func (c *Core) SetParameterValue(modelPtr uintptr, id string, value float32) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	inst := c.get(modelPtr)
+	for i, p := range inst.model.Parameters {
+		if p.Id == id {
+			inst.values[i] = value
+			return
+		}
+	}
+}
+
+// Get the part IDs
+func (c *Core) GetPartIds(modelPtr uintptr) (ids []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, p := range c.get(modelPtr).model.Parts {
+		ids = append(ids, p.Id)
+	}
+	return
+}
+
+// Get the parts
+func (c *Core) GetParts(modelPtr uintptr) (parts []part.Part) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	inst := c.get(modelPtr)
+	for i, p := range inst.model.Parts {
+		p.Opacity = inst.partOpacities[i]
+		parts = append(parts, p)
+	}
+	return
+}
+
+// Get the part's opacity
+func (c *Core) GetPartOpacity(modelPtr uintptr, id string) float32 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	inst := c.get(modelPtr)
+	for i, p := range inst.model.Parts {
+		if p.Id == id {
+			return inst.partOpacities[i]
+		}
+	}
+	return 0
+}
+
+// Set the part's opacity
+func (c *Core) SetPartOpacity(modelPtr uintptr, id string, value float32) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	inst := c.get(modelPtr)
+	for i, p := range inst.model.Parts {
+		if p.Id == id {
+			inst.partOpacities[i] = value
+			return
+		}
+	}
+}
+
+// Get the drawing order
+// The drawables are always drawn in the order they are defined
+func (c *Core) GetSortedDrawableIndices(modelPtr uintptr) (rs []int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append(rs, c.get(modelPtr).sortedIndices...)
+}
+
+// Get the render orders
+// Like the native core, the returned slice is overwritten by Update
+func (c *Core) GetRenderOrders(modelPtr uintptr) []int32 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.get(modelPtr).renderOrders
+}
+
+// Get the canvas info
+func (c *Core) GetCanvasInfo(modelPtr uintptr) (size drawable.Vector2, origin drawable.Vector2, pixelsPerUnit float32) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	m := c.get(modelPtr).model
+	return m.CanvasSize, m.CanvasOrigin, m.PixelsPerUnit
+}
+
+// Update the model
+func (c *Core) Update(modelPtr uintptr) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.get(modelPtr).update()
+}
+
+// Recalculate the vertex positions and opacities and set the dynamic flags
+func (inst *instance) update() {
+	for i, d := range inst.model.Drawables {
+		var flag uint8
+		// Offset of the vertices
+		var dx, dy float32
+		for _, deformer := range d.Deformers {
+			v := inst.value(deformer.ParameterId)
+			dx += v * deformer.Offset.X
+			dy += v * deformer.Offset.Y
+		}
+		for j, p := range d.VertexPositions {
+			next := drawable.Vector2{X: p.X + dx, Y: p.Y + dy}
+			if inst.positions[i][j] != next {
+				inst.positions[i][j] = next
+				flag |= flagVertexPositionsDidChange
+			}
+		}
+		opacity := inst.opacity(i)
+		if inst.opacities[i] != opacity {
+			if (inst.opacities[i] > 0) != (opacity > 0) {
+				flag |= flagVisibilityDidChange
+			}
+			inst.opacities[i] = opacity
+			flag |= flagOpacityDidChange
+		}
+		if !inst.updated {
+			flag |= flagVisibilityDidChange | flagOpacityDidChange | flagDrawOrderDidChange | flagRenderOrderDidChange | flagVertexPositionsDidChange
+		}
+		if opacity > 0 {
+			flag |= flagIsVisible
+		}
+		inst.flags[i] = flag
+	}
+	inst.updated = true
+}
+
+// Calculate the initial state without setting the dynamic flags
+func (inst *instance) deform() {
+	inst.update()
+	for i := range inst.flags {
+		inst.flags[i] &= flagIsVisible
+	}
+	inst.updated = false
+}
+
+// Get the current value of the parameter
+func (inst *instance) value(id string) float32 {
+	for i, p := range inst.model.Parameters {
+		if p.Id == id {
+			return inst.values[i]
+		}
+	}
+	return 0
+}
+
+// Get the opacity of the drawable multiplied by the opacities of its ancestor parts
+func (inst *instance) opacity(index int) float32 {
+	d := inst.model.Drawables[index]
+	opacity := d.Opacity
+	for p := d.Parent; p >= 0; p = inst.model.Parts[p].Parent {
+		opacity *= inst.partOpacities[p]
+	}
+	return opacity
+}
@@ -0,0 +1,62 @@
+package fake_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/aethiopicuschan/cubism-go/core/drawable"
+	"github.com/aethiopicuschan/cubism-go/core/fake"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadMoc(t *testing.T) {
+	t.Parallel()
+	c := fake.NewCore(fake.FixtureModel())
+	_, err := c.LoadMoc(filepath.Join(t.TempDir(), "missing.moc3"))
+	assert.Error(t, err)
+
+	c, m := fake.LoadFixture(t)
+	modelPtr := m.ModelPtr
+	assert.Len(t, c.GetDrawables(modelPtr), 6)
+	assert.Len(t, c.GetParameters(modelPtr), 6)
+	assert.Equal(t, []string{"PartRoot", "PartBody", "PartFace", "PartArmA", "PartArmB"}, c.GetPartIds(modelPtr))
+	assert.Equal(t, float32(1), c.GetParameterValue(modelPtr, "ParamEyeLOpen"))
+}
+
+func TestUpdate(t *testing.T) {
+	t.Parallel()
+	c, m := fake.LoadFixture(t)
+	modelPtr := m.ModelPtr
+
+	// Every flag is set by the first update
+	c.Update(modelPtr)
+	for _, f := range c.GetDynamicFlags(modelPtr) {
+		assert.True(t, f.VertexPositionsDidChange)
+		assert.True(t, f.OpacityDidChange)
+	}
+
+	// Nothing changes without touching the parameters
+	c.Update(modelPtr)
+	for _, f := range c.GetDynamicFlags(modelPtr) {
+		assert.False(t, f.VertexPositionsDidChange)
+		assert.False(t, f.OpacityDidChange)
+	}
+
+	// Only the drawable bound to the parameter is deformed
+	c.SetParameterValue(modelPtr, "ParamAngleX", 10)
+	c.Update(modelPtr)
+	flags := c.GetDynamicFlags(modelPtr)
+	assert.True(t, flags[0].VertexPositionsDidChange)
+	assert.False(t, flags[1].VertexPositionsDidChange)
+	assert.Equal(t, drawable.Vector2{X: -0.4, Y: -1}, c.GetVertexPositions(modelPtr)[0][0])
+
+	// The opacity of a part is applied to its descendants
+	c.SetPartOpacity(modelPtr, "PartFace", 0.5)
+	c.Update(modelPtr)
+	flags = c.GetDynamicFlags(modelPtr)
+	opacities := c.GetOpacities(modelPtr)
+	assert.True(t, flags[1].OpacityDidChange)
+	assert.Equal(t, float32(0.5), opacities[1])
+	assert.Equal(t, float32(1), opacities[0])
+	assert.False(t, flags[5].IsVisible)
+}
@@ -0,0 +1,126 @@
+package fake
+
+import (
+	"bytes"
+	"embed"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/png"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aethiopicuschan/cubism-go/core/moc"
+)
+
+//go:embed fixture
+var fixture embed.FS
+
+// Name of model3.json written by [WriteFixture]
+const FixtureName = "Fake.model3.json"
+
+// Write the set of model files matching [FixtureModel] into dir
+// The path of model3.json is returned
+func WriteFixture(dir string) (path string, err error) {
+	err = fs.WalkDir(fixture, "fixture", func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel("fixture", p)
+		if err != nil {
+			return err
+		}
+		buf, err := fixture.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		return writeFile(filepath.Join(dir, rel), buf)
+	})
+	if err != nil {
+		return
+	}
+	if err = writeFile(filepath.Join(dir, "Fake.moc3"), Moc3(5)); err != nil {
+		return
+	}
+	if err = writeFile(filepath.Join(dir, "Fake.png"), texture()); err != nil {
+		return
+	}
+	if err = writeFile(filepath.Join(dir, "sounds", "Tap.wav"), sound()); err != nil {
+		return
+	}
+	path = filepath.Join(dir, FixtureName)
+	return
+}
+
+// Write the fixture into a temporary directory of the test and load it with a new core
+// The test fails immediately if it cannot be loaded
+func LoadFixture(tb testing.TB) (c *Core, m moc.Moc) {
+	tb.Helper()
+	path, err := WriteFixture(tb.TempDir())
+	if err != nil {
+		tb.Fatal(err)
+	}
+	c = NewCore(FixtureModel())
+	m, err = c.LoadMoc(filepath.Join(filepath.Dir(path), "Fake.moc3"))
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return
+}
+
+// Create the content of a moc3 file with the specified format version
+// Only the header is meaningful
+func Moc3(version uint8) []byte {
+	buf := make([]byte, 64)
+	copy(buf, "MOC3")
+	buf[4] = version
+	return buf
+}
+
+func writeFile(path string, buf []byte) (err error) {
+	if err = os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return
+	}
+	return os.WriteFile(path, buf, 0o644)
+}
+
+// Create a small opaque texture
+func texture() []byte {
+	img := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 4; x++ {
+			img.Set(x, y, color.RGBA{R: 255, G: 255, B: 255, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	png.Encode(&buf, img)
+	return buf.Bytes()
+}
+
+// Create a short silent 8-bit mono WAV file
+func sound() []byte {
+	const sampleRate = 8000
+	samples := make([]byte, sampleRate/10)
+	for i := range samples {
+		samples[i] = 128
+	}
+	var buf bytes.Buffer
+	buf.WriteString("RIFF")
+	binary.Write(&buf, binary.LittleEndian, uint32(36+len(samples)))
+	buf.WriteString("WAVEfmt ")
+	binary.Write(&buf, binary.LittleEndian, uint32(16))
+	// PCM, mono
+	binary.Write(&buf, binary.LittleEndian, uint16(1))
+	binary.Write(&buf, binary.LittleEndian, uint16(1))
+	binary.Write(&buf, binary.LittleEndian, uint32(sampleRate))
+	// Bytes per second, block align and bits per sample
+	binary.Write(&buf, binary.LittleEndian, uint32(sampleRate))
+	binary.Write(&buf, binary.LittleEndian, uint16(1))
+	binary.Write(&buf, binary.LittleEndian, uint16(8))
+	buf.WriteString("data")
+	binary.Write(&buf, binary.LittleEndian, uint32(len(samples)))
+	buf.Write(samples)
+	return buf.Bytes()
+}
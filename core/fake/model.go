@@ -0,0 +1,117 @@
+package fake
+
+import (
+	"github.com/aethiopicuschan/cubism-go/core/drawable"
+	"github.com/aethiopicuschan/cubism-go/core/parameter"
+	"github.com/aethiopicuschan/cubism-go/core/part"
+)
+
+// Definition of the model instantiated by [Core.LoadMoc]
+type Model struct {
+	CanvasSize    drawable.Vector2
+	CanvasOrigin  drawable.Vector2
+	PixelsPerUnit float32
+	// Current is ignored and Default is used as the initial value
+	Parameters []parameter.Parameter
+	Parts      []part.Part
+	Drawables  []Drawable
+}
+
+// Definition of a drawable of the fake model
+type Drawable struct {
+	Id           string
+	Texture      int32
+	ConstantFlag uint8
+	// Positions of the vertices when no deformer is applied
+	VertexPositions []drawable.Vector2
+	VertexUvs       []drawable.Vector2
+	VertexIndices   []uint16
+	Opacity         float32
+	Masks           []int32
+	Parent          int
+	// The vertices are translated by the sum of the parameter values multiplied by the offsets
+	Deformers []Deformer
+}
+
+// Binding of a parameter to the vertex positions of a drawable
+type Deformer struct {
+	ParameterId string
+	Offset      drawable.Vector2
+}
+
+// A quad covering the square from (x, y) to (x+size, y+size)
+func Quad(x, y, size float32) (positions, uvs []drawable.Vector2, indices []uint16) {
+	positions = []drawable.Vector2{
+		{X: x, Y: y},
+		{X: x + size, Y: y},
+		{X: x + size, Y: y + size},
+		{X: x, Y: y + size},
+	}
+	uvs = []drawable.Vector2{
+		{X: 0, Y: 0},
+		{X: 1, Y: 0},
+		{X: 1, Y: 1},
+		{X: 0, Y: 1},
+	}
+	indices = []uint16{0, 1, 2, 0, 2, 3}
+	return
+}
+
+// Definition of the model matching the fixture written by [WriteFixture]
+func FixtureModel() (m Model) {
+	m.CanvasSize = drawable.Vector2{X: 512, Y: 512}
+	m.CanvasOrigin = drawable.Vector2{X: 256, Y: 256}
+	m.PixelsPerUnit = 256
+	m.Parameters = []parameter.Parameter{
+		{Id: "ParamAngleX", Type: parameter.Normal, Minimum: -30, Maximum: 30, Default: 0, KeyValues: []float32{-30, 0, 30}},
+		{Id: "ParamEyeLOpen", Type: parameter.Normal, Minimum: 0, Maximum: 1, Default: 1, KeyValues: []float32{0, 1}},
+		{Id: "ParamEyeROpen", Type: parameter.Normal, Minimum: 0, Maximum: 1, Default: 1, KeyValues: []float32{0, 1}},
+		{Id: "ParamMouthOpenY", Type: parameter.Normal, Minimum: 0, Maximum: 1, Default: 0, KeyValues: []float32{0, 1}},
+		{Id: "ParamBodyAngleZ", Type: parameter.Normal, Minimum: -180, Maximum: 180, Default: 0, Repeat: true, KeyValues: []float32{-180, 0, 180}},
+		{Id: "ParamSmile", Type: parameter.BlendShape, Minimum: 0, Maximum: 1, Default: 0, KeyValues: []float32{0, 1}},
+	}
+	m.Parts = []part.Part{
+		{Id: "PartRoot", Opacity: 1, Parent: -1},
+		{Id: "PartBody", Opacity: 1, Parent: 0},
+		{Id: "PartFace", Opacity: 1, Parent: 0},
+		{Id: "PartArmA", Opacity: 1, Parent: 1},
+		{Id: "PartArmB", Opacity: 0, Parent: 1},
+	}
+	body, bodyUvs, bodyIndices := Quad(-0.5, -1, 1)
+	eyeL, eyeLUvs, eyeLIndices := Quad(-0.3, 0.4, 0.1)
+	eyeR, eyeRUvs, eyeRIndices := Quad(0.2, 0.4, 0.1)
+	mouth, mouthUvs, mouthIndices := Quad(-0.05, 0.1, 0.1)
+	armA, armAUvs, armAIndices := Quad(-0.9, -0.5, 0.4)
+	armB, armBUvs, armBIndices := Quad(0.5, -0.5, 0.4)
+	m.Drawables = []Drawable{
+		{
+			Id: "HitAreaBody", Texture: 0, Opacity: 1, Parent: 1,
+			VertexPositions: body, VertexUvs: bodyUvs, VertexIndices: bodyIndices,
+			Deformers: []Deformer{{ParameterId: "ParamAngleX", Offset: drawable.Vector2{X: 0.01}}},
+		},
+		{
+			Id: "EyeL", Texture: 0, Opacity: 1, Parent: 2,
+			VertexPositions: eyeL, VertexUvs: eyeLUvs, VertexIndices: eyeLIndices,
+			Deformers: []Deformer{{ParameterId: "ParamEyeLOpen", Offset: drawable.Vector2{Y: 0.05}}},
+		},
+		{
+			Id: "EyeR", Texture: 0, Opacity: 1, Parent: 2,
+			VertexPositions: eyeR, VertexUvs: eyeRUvs, VertexIndices: eyeRIndices,
+			Deformers: []Deformer{{ParameterId: "ParamEyeROpen", Offset: drawable.Vector2{Y: 0.05}}},
+		},
+		{
+			Id: "Mouth", Texture: 0, Opacity: 1, Parent: 2, Masks: []int32{0},
+			VertexPositions: mouth, VertexUvs: mouthUvs, VertexIndices: mouthIndices,
+			Deformers: []Deformer{{ParameterId: "ParamMouthOpenY", Offset: drawable.Vector2{Y: -0.05}}},
+		},
+		{
+			Id: "ArmA", Texture: 0, Opacity: 1, Parent: 3,
+			VertexPositions: armA, VertexUvs: armAUvs, VertexIndices: armAIndices,
+		},
+		{
+			Id: "ArmB", Texture: 0, Opacity: 1, Parent: 4,
+			VertexPositions: armB, VertexUvs: armBUvs, VertexIndices: armBIndices,
+		},
+	}
+	return
+}
@@ -0,0 +1,15 @@
+package moc
+
+import "errors"
+
+// The moc3 file did not pass the consistency check of the Cubism Core
+var ErrInconsistentMoc = errors.New("moc3 is not consistent")
+
+type Moc struct {
+	MocPtr      uintptr
+	MocBuffer   []byte
+	ModelPtr    uintptr
+	ModelBuffer []byte
+	// Format version of the moc3 file
+	Version Version
+}
@@ -0,0 +1,62 @@
+package moc
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+)
+
+// Format version of moc3 files
+type Version uint32
+
+const (
+	VersionUnknown Version = iota
+	Version30              // 3.0.00 - 3.2.07
+	Version33              // 3.3.00 - 3.3.03
+	Version40              // 4.0.00 - 4.1.05
+	Version42              // 4.2.00 - 4.2.04
+	Version50              // 5.0.00 -
+)
+
+// The buffer is not a moc3 file
+var ErrInvalidMoc = errors.New("not a moc3 file")
+
+func (v Version) String() string {
+	switch v {
+	case Version30:
+		return "3.0"
+	case Version33:
+		return "3.3"
+	case Version40:
+		return "4.0"
+	case Version42:
+		return "4.2"
+	case Version50:
+		return "5.0"
+	case VersionUnknown:
+		return "unknown"
+	}
+	return fmt.Sprintf("unknown (%d)", uint32(v))
+}
+
+// Read the format version from the header of a moc3 file
+// It does not require the Cubism Core, and versions newer than the known ones are returned as is
+func ReadVersion(buf []byte) (v Version, err error) {
+	// The header starts with the signature followed by the version
+	if len(buf) < 5 || !bytes.HasPrefix(buf, []byte("MOC3")) {
+		err = ErrInvalidMoc
+		return
+	}
+	v = Version(buf[4])
+	return
+}
+
+// The moc3 file is newer than the latest version supported by the Cubism Core
+type ErrMocTooNew struct {
+	Version Version
+	Latest  Version
+}
+
+func (e *ErrMocTooNew) Error() string {
+	return fmt.Sprintf("moc3 version %s is newer than %s supported by the core", e.Version, e.Latest)
+}
@@ -0,0 +1,58 @@
+package moc_test
+
+import (
+	"testing"
+
+	"github.com/aethiopicuschan/cubism-go/core/moc"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadVersion(t *testing.T) {
+	testcases := []struct {
+		name   string
+		src    []byte
+		expect moc.Version
+		err    error
+	}{
+		{
+			name:   "3.0",
+			src:    []byte{'M', 'O', 'C', '3', 1, 0},
+			expect: moc.Version30,
+		},
+		{
+			name:   "5.0",
+			src:    []byte{'M', 'O', 'C', '3', 5, 0},
+			expect: moc.Version50,
+		},
+		{
+			name:   "newer",
+			src:    []byte{'M', 'O', 'C', '3', 6, 0},
+			expect: moc.Version(6),
+		},
+		{
+			name: "signature",
+			src:  []byte{'M', 'O', 'C', '2', 5, 0},
+			err:  moc.ErrInvalidMoc,
+		},
+		{
+			name: "truncated",
+			src:  []byte{'M', 'O', 'C', '3'},
+			err:  moc.ErrInvalidMoc,
+		},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := moc.ReadVersion(testcase.src)
+			assert.ErrorIs(t, err, testcase.err)
+			assert.Equal(t, testcase.expect, got)
+		})
+	}
+}
+
+func TestVersionString(t *testing.T) {
+	assert.Equal(t, "4.2", moc.Version42.String())
+	assert.Equal(t, "unknown", moc.VersionUnknown.String())
+	assert.Equal(t, "unknown (6)", moc.Version(6).String())
+}
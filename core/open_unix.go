@@ -7,3 +7,7 @@ import "github.com/ebitengine/purego"
 func openLibrary(name string) (uintptr, error) {
 	return purego.Dlopen(name, purego.RTLD_NOW|purego.RTLD_GLOBAL)
 }
+
+func closeLibrary(handle uintptr) error {
+	return purego.Dlclose(handle)
+}
@@ -10,3 +10,7 @@ func openLibrary(name string) (uintptr, error) {
 	handle, err := windows.LoadDLL(name)
 	return uintptr(handle.Handle), err
 }
+
+func closeLibrary(handle uintptr) error {
+	return windows.FreeLibrary(windows.Handle(handle))
+}
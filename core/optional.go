@@ -0,0 +1,163 @@
+package core
+
+import (
+	"github.com/aethiopicuschan/cubism-go/core/drawable"
+	"github.com/aethiopicuschan/cubism-go/core/moc"
+	"github.com/aethiopicuschan/cubism-go/core/part"
+)
+
+// The interfaces below are optional capabilities of a [Core], checked by type assertion
+// The implementations returned by [NewCore] implement all of them
+// The functions taking a [Core] use them when they are implemented and fall back to the methods of [Core] otherwise
+
+// A core reporting the versions of moc3 files
+type MocVersioner interface {
+	// Get the latest moc3 version supported
+	GetLatestMocVersion() moc.Version
+	// Get the version of a moc3 file
+	GetMocVersion([]byte) moc.Version
+}
+
+// A core forwarding its log messages
+type LogSetter interface {
+	// Set the function receiving the log messages, nil discards them
+	SetLogFunction(func(string))
+}
+
+// A core exposing the parts of the models
+type PartReader interface {
+	GetParts(uintptr) []part.Part
+	GetPartOpacity(uintptr, string) float32
+}
+
+// A core exposing the packed dynamic flags of the drawables
+type DynamicFlagBitsReader interface {
+	// Get the packed dynamic flags of the drawables without copying
+	// The slice refers to the memory of the core, which Update overwrites and ReleaseMoc frees
+	GetDynamicFlagBits(uintptr) []uint8
+}
+
+// A core exposing the render orders of the drawables
+type RenderOrderReader interface {
+	// Get the render orders of the drawables without copying
+	// The slice refers to the memory of the core, which Update overwrites and ReleaseMoc frees
+	GetRenderOrders(uintptr) []int32
+}
+
+// A core freeing the models loaded by it
+type MocReleaser interface {
+	// Release the model loaded by LoadMoc
+	// The pointers of the moc must not be used afterwards
+	ReleaseMoc(moc.Moc)
+}
+
+// A core holding a library to close
+type Closer interface {
+	// Close the library
+	// The core and the models loaded by it must not be used afterwards
+	Close() error
+}
+
+// Get the latest moc3 version supported by the core, or [moc.VersionUnknown] if it does not tell
+func GetLatestMocVersion(c Core) moc.Version {
+	if v, ok := c.(MocVersioner); ok {
+		return v.GetLatestMocVersion()
+	}
+	return moc.VersionUnknown
+}
+
+// Get the version of a moc3 file, read from its header if the core does not tell
+func GetMocVersion(c Core, buf []byte) (v moc.Version) {
+	if mv, ok := c.(MocVersioner); ok {
+		return mv.GetMocVersion(buf)
+	}
+	v, _ = moc.ReadVersion(buf)
+	return
+}
+
+// Set the function receiving the log messages of the core, if it forwards them
+func SetLogFunction(c Core, f func(string)) {
+	if l, ok := c.(LogSetter); ok {
+		l.SetLogFunction(f)
+	}
+}
+
+// Get the parts of the model
+// Without [PartReader], the parts are fully opaque and have no parent
+func GetParts(c Core, modelPtr uintptr) (parts []part.Part) {
+	if r, ok := c.(PartReader); ok {
+		return r.GetParts(modelPtr)
+	}
+	for _, id := range c.GetPartIds(modelPtr) {
+		parts = append(parts, part.Part{Id: id, Opacity: 1, Parent: -1})
+	}
+	return
+}
+
+// Get the opacity of the part, 1 without [PartReader]
+func GetPartOpacity(c Core, modelPtr uintptr, id string) float32 {
+	if r, ok := c.(PartReader); ok {
+		return r.GetPartOpacity(modelPtr, id)
+	}
+	return 1
+}
+
+// Get the packed dynamic flags of the drawables
+// Without [DynamicFlagBitsReader], they are packed from the dynamic flags into a new slice
+func GetDynamicFlagBits(c Core, modelPtr uintptr) (bits []uint8) {
+	if r, ok := c.(DynamicFlagBitsReader); ok {
+		return r.GetDynamicFlagBits(modelPtr)
+	}
+	flags := c.GetDynamicFlags(modelPtr)
+	bits = make([]uint8, len(flags))
+	for i, f := range flags {
+		bits[i] = packDynamicFlag(f)
+	}
+	return
+}
+
+func packDynamicFlag(f drawable.DynamicFlag) (bits uint8) {
+	for i, set := range []bool{
+		f.IsVisible,
+		f.VisibilityDidChange,
+		f.OpacityDidChange,
+		f.DrawOrderDidChange,
+		f.RenderOrderDidChange,
+		f.VertexPositionsDidChange,
+		f.BlendColorDidChange,
+	} {
+		if set {
+			bits |= 1 << i
+		}
+	}
+	return
+}
+
+// Get the render orders of the drawables
+// Without [RenderOrderReader], they are computed from the sorted drawable indices into a new slice
+func GetRenderOrders(c Core, modelPtr uintptr) (orders []int32) {
+	if r, ok := c.(RenderOrderReader); ok {
+		return r.GetRenderOrders(modelPtr)
+	}
+	sorted := c.GetSortedDrawableIndices(modelPtr)
+	orders = make([]int32, len(sorted))
+	for order, i := range sorted {
+		orders[i] = int32(order)
+	}
+	return
+}
+
+// Release the model loaded by LoadMoc, if the core frees its models
+func ReleaseMoc(c Core, m moc.Moc) {
+	if r, ok := c.(MocReleaser); ok {
+		r.ReleaseMoc(m)
+	}
+}
+
+// Close the library of the core, if it holds one
+func Close(c Core) error {
+	if cl, ok := c.(Closer); ok {
+		return cl.Close()
+	}
+	return nil
+}
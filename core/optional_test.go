@@ -0,0 +1,49 @@
+package core_test
+
+import (
+	"testing"
+
+	"github.com/aethiopicuschan/cubism-go/core"
+	"github.com/aethiopicuschan/cubism-go/core/fake"
+	"github.com/aethiopicuschan/cubism-go/core/moc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// A core with only the methods of [core.Core], hiding the optional ones
+type baseCore struct {
+	core.Core
+}
+
+func TestOptionalFallbacks(t *testing.T) {
+	t.Parallel()
+	full, m := fake.LoadFixture(t)
+	c := baseCore{full}
+	full.Update(m.ModelPtr)
+
+	// The fallbacks agree with the implementation where the data is available
+	assert.Equal(t, full.GetDynamicFlagBits(m.ModelPtr), core.GetDynamicFlagBits(c, m.ModelPtr))
+	assert.Equal(t, full.GetRenderOrders(m.ModelPtr), core.GetRenderOrders(c, m.ModelPtr))
+	buf := fake.Moc3(5)
+	v, err := moc.ReadVersion(buf)
+	require.NoError(t, err)
+	assert.Equal(t, v, core.GetMocVersion(c, buf))
+
+	// Otherwise they return neutral values
+	assert.Equal(t, moc.VersionUnknown, core.GetLatestMocVersion(c))
+	parts := core.GetParts(c, m.ModelPtr)
+	require.Len(t, parts, len(full.GetPartIds(m.ModelPtr)))
+	for i, p := range parts {
+		assert.Equal(t, full.GetPartIds(m.ModelPtr)[i], p.Id)
+		assert.Equal(t, float32(1), p.Opacity)
+		assert.Equal(t, -1, p.Parent)
+	}
+	assert.Equal(t, float32(1), core.GetPartOpacity(c, m.ModelPtr, "PartArmA"))
+	core.SetLogFunction(c, func(string) {})
+	core.ReleaseMoc(c, m)
+	assert.NoError(t, core.Close(c))
+
+	// The implementation is used when it is there
+	assert.Equal(t, full.GetParts(m.ModelPtr), core.GetParts(full, m.ModelPtr))
+	assert.Equal(t, full.GetLatestMocVersion(), core.GetLatestMocVersion(full))
+}
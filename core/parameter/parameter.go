@@ -0,0 +1,27 @@
+package parameter
+
+// Type of a parameter
+type Type int
+
+// Types of parameters
+const (
+	Normal Type = iota
+	BlendShape
+)
+
+type Parameter struct {
+	Id      string
+	Type    Type
+	Minimum float32
+	Maximum float32
+	Default float32
+	Current float32
+	// Always false if the core does not support it
+	Repeat bool
+	// nil if the core does not support it
+	KeyValues []float32
+	// Display information from cdi3.json
+	Name      string
+	GroupId   string
+	GroupName string
+}
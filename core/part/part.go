@@ -0,0 +1,8 @@
+package part
+
+type Part struct {
+	Id      string
+	Opacity float32
+	// Index of the parent part, -1 if the part is at the root
+	Parent int
+}
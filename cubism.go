@@ -2,12 +2,17 @@ package cubism
 
 import (
 	"encoding/json"
+	"errors"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"strings"
 
-	"github.com/aethiopicuschan/cubism-go/internal/core"
+	"github.com/aethiopicuschan/cubism-go/core"
+	"github.com/aethiopicuschan/cubism-go/core/moc"
 	"github.com/aethiopicuschan/cubism-go/internal/model"
-	"github.com/aethiopicuschan/cubism-go/internal/motion"
+	internalmotion "github.com/aethiopicuschan/cubism-go/internal/motion"
+	"github.com/aethiopicuschan/cubism-go/motion"
 	"github.com/aethiopicuschan/cubism-go/sound"
 	"github.com/aethiopicuschan/cubism-go/sound/disabled"
 )
@@ -16,7 +21,8 @@ import (
 The main body of cubism-go
 */
 type Cubism struct {
-	core core.Core
+	core      core.Core
+	lifecycle *lifecycle
 	// A function to load audio files
 	LoadSound func(fp string) (s sound.Sound, err error)
 }
@@ -24,32 +30,115 @@ type Cubism struct {
 // Constructor for the [Cubism] struct
 func NewCubism(lib string) (c Cubism, err error) {
 	c.core, err = core.NewCore(lib)
+	c.lifecycle = newLifecycle()
+	return
+}
+
+// Constructor for the [Cubism] struct using an existing implementation of the core
+// This is mainly useful for testing with the core/fake package
+func NewCubismFromCore(core core.Core) Cubism {
+	return Cubism{
+		core:      core,
+		lifecycle: newLifecycle(),
+	}
+}
+
+// Close the Cubism Core and all the models loaded by it
+// The copies of the struct are closed as well, and using them afterwards returns [ErrClosed]
+func (c *Cubism) Close() (err error) {
+	models, ok := c.lifecycle.close()
+	if !ok {
+		return ErrClosed
+	}
+	errs := []error{}
+	for _, m := range models {
+		errs = append(errs, m.Close())
+	}
+	errs = append(errs, core.Close(c.core))
+	return errors.Join(errs...)
+}
+
+// Set the logger receiving the log messages of the Cubism Core
+// The messages explain, for example, why a moc3 file is rejected
+// Passing nil discards the messages
+func (c *Cubism) SetLogger(l *slog.Logger) {
+	if c.lifecycle.isClosed() {
+		return
+	}
+	if l == nil {
+		core.SetLogFunction(c.core, nil)
+		return
+	}
+	core.SetLogFunction(c.core, func(message string) {
+		l.Warn(strings.TrimSpace(message), "source", "cubism-core")
+	})
+}
+
+// Get the version of the Cubism Core
+func (c *Cubism) GetCoreVersion() string {
+	if c.lifecycle.isClosed() {
+		return ""
+	}
+	return c.core.GetVersion()
+}
+
+// Get the latest moc3 version supported by the Cubism Core
+func (c *Cubism) GetLatestMocVersion() moc.Version {
+	if c.lifecycle.isClosed() {
+		return moc.VersionUnknown
+	}
+	return core.GetLatestMocVersion(c.core)
+}
+
+// Get the version of a moc3 file as reported by the Cubism Core
+// Use [moc.ReadVersion] to read it without the Cubism Core
+func (c *Cubism) GetMocVersion(path string) (v moc.Version, err error) {
+	if c.lifecycle.isClosed() {
+		err = ErrClosed
+		return
+	}
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return
+	}
+	v = core.GetMocVersion(c.core, buf)
 	return
 }
 
 // Load a model from model3.json
-func (c *Cubism) LoadModel(path string) (m *Model, err error) {
+// The errors caused by the files of the model are returned as [*LoadError]
+// If the moc3 file is newer than the Cubism Core, the error wraps [*moc.ErrMocTooNew]
+func (c *Cubism) LoadModel(path string, opts ...func(*LoadOption)) (m *Model, err error) {
+	opt := &LoadOption{}
+	for _, o := range opts {
+		o(opt)
+	}
+
+	if c.lifecycle.isClosed() {
+		err = ErrClosed
+		return
+	}
+
 	m = &Model{
-		core:    c.core,
-		opacity: 1.0,
+		core:      c.core,
+		lifecycle: c.lifecycle,
+		opacity:   1.0,
+		timeScale: 1.0,
 	}
 
 	// Get the absolute path
 	absPath, err := filepath.Abs(path)
 	if err != nil {
+		err = &LoadError{Kind: FileModel, Path: path, Err: err}
 		return
 	}
 	// Get the directory
 	dir := filepath.Dir(absPath)
 
 	// Read model3.json
-	buf, err := os.ReadFile(absPath)
-	if err != nil {
-		return
-	}
 	// Convert to a structure compatible with version 3
 	var mj model.ModelJson
-	if err = json.Unmarshal(buf, &mj); err != nil {
+	if err = loadJson(FileModel, absPath, &mj); err != nil {
 		return
 	}
 
@@ -62,14 +151,25 @@ func (c *Cubism) LoadModel(path string) (m *Model, err error) {
 	}
 
 	m.groups = mj.Groups
-	m.hitAreas = mj.HitAreas
+	for _, h := range mj.HitAreas {
+		m.hitAreas = append(m.hitAreas, HitArea{
+			Id:   h.Id,
+			Name: h.Name,
+		})
+	}
 
 	// Load the moc3 file
 	moc3Path := filepath.Join(dir, mj.FileReferences.Moc)
 	m.moc, err = c.core.LoadMoc(moc3Path)
 	if err != nil {
+		err = &LoadError{Kind: FileMoc, Path: moc3Path, Err: err}
 		return
 	}
+	defer func() {
+		if err != nil {
+			core.ReleaseMoc(c.core, m.moc)
+		}
+	}()
 	// Get the Drawables
 	ds := c.core.GetDrawables(m.moc.ModelPtr)
 	for _, d := range ds {
@@ -83,12 +183,13 @@ func (c *Cubism) LoadModel(path string) (m *Model, err error) {
 			DynamicFlag:     d.DynamicFlag,
 			Opacity:         d.Opacity,
 			Masks:           d.Masks,
+			Parent:          d.Parent,
 		})
 	}
 	// Create map of Drawables
-	m.drawablesMap = map[string]Drawable{}
-	for _, d := range m.drawables {
-		m.drawablesMap[d.Id] = d
+	m.drawablesMap = map[string]int{}
+	for i, d := range m.drawables {
+		m.drawablesMap[d.Id] = i
 	}
 	// Get the sorted indices
 	m.sortedIndices = c.core.GetSortedDrawableIndices(m.moc.ModelPtr)
@@ -96,97 +197,160 @@ func (c *Cubism) LoadModel(path string) (m *Model, err error) {
 	// Load the physics settings if they exist
 	if mj.FileReferences.Physics != "" {
 		physicsPath := filepath.Join(dir, mj.FileReferences.Physics)
-		buf, err = os.ReadFile(physicsPath)
-		if err != nil {
-			return
-		}
-		if err = json.Unmarshal(buf, &m.physics); err != nil {
-			return
+		if err = loadJson(FilePhysics, physicsPath, &m.physics); err != nil {
+			if err = opt.tolerate(m, err); err != nil {
+				return
+			}
+			m.physics = model.PhysicsJson{}
 		}
 	}
 
 	// Load the pose settings if they exist
 	if mj.FileReferences.Pose != "" {
 		posePath := filepath.Join(dir, mj.FileReferences.Pose)
-		buf, err = os.ReadFile(posePath)
-		if err != nil {
-			return
-		}
-		if err = json.Unmarshal(buf, &m.pose); err != nil {
-			return
+		if err = loadJson(FilePose, posePath, &m.pose); err != nil {
+			if err = opt.tolerate(m, err); err != nil {
+				return
+			}
+			m.pose = model.PoseJson{}
 		}
 	}
 
 	// Load the display info settings if they exist
 	if mj.FileReferences.DisplayInfo != "" {
 		displayInfoPath := filepath.Join(dir, mj.FileReferences.DisplayInfo)
-		buf, err = os.ReadFile(displayInfoPath)
-		if err != nil {
-			return
+		if err = loadJson(FileDisplayInfo, displayInfoPath, &m.cdi); err != nil {
+			if err = opt.tolerate(m, err); err != nil {
+				return
+			}
+			m.cdi = model.CdiJson{}
 		}
-		if err = json.Unmarshal(buf, &m.cdi); err != nil {
-			return
+	}
+
+	// Build the part hierarchy
+	for _, p := range core.GetParts(c.core, m.moc.ModelPtr) {
+		m.parts = append(m.parts, Part{
+			Id:     p.Id,
+			Name:   m.cdi.GetPartName(p.Id),
+			Parent: p.Parent,
+		})
+	}
+	for i, p := range m.parts {
+		if p.Parent >= 0 {
+			m.parts[p.Parent].Children = append(m.parts[p.Parent].Children, i)
+		}
+	}
+	for i, d := range m.drawables {
+		if d.Parent >= 0 {
+			m.parts[d.Parent].Drawables = append(m.parts[d.Parent].Drawables, i)
 		}
 	}
 
 	// Load the expressions
 	for _, exp := range mj.FileReferences.Expressions {
 		expPath := filepath.Join(dir, exp.File)
-		buf, err = os.ReadFile(expPath)
-		if err != nil {
-			return
-		}
 		var e model.ExpJson
-		if err = json.Unmarshal(buf, &e); err != nil {
-			return
+		if err = loadJson(FileExpression, expPath, &e); err != nil {
+			if err = opt.tolerate(m, err); err != nil {
+				return
+			}
+			continue
 		}
 		e.Name = exp.Name
 		m.exps = append(m.exps, e)
 	}
 
 	// Load the motion settings
-	m.motions = map[string][]motion.Motion{}
+	refs := map[string][]motionRef{}
+	if opt.skipMotions {
+		mj.FileReferences.Motions = nil
+	}
 	for name, motions := range mj.FileReferences.Motions {
-		m.motions[name] = []motion.Motion{}
+		refs[name] = []motionRef{}
 		for _, motion := range motions {
-			motionPath := filepath.Join(dir, motion.File)
-			buf, err = os.ReadFile(motionPath)
-			if err != nil {
-				return
-			}
-			var mtnJson model.MotionJson
-			if err = json.Unmarshal(buf, &mtnJson); err != nil {
-				return
-			}
-			fp := filepath.Base(motion.File)
-			motion := mtnJson.ToMotion(fp, motion.FadeInTime, motion.FadeOutTime, motion.Sound)
-			if motion.Sound != "" {
-				soundPath := filepath.Join(dir, motion.Sound)
-				// If LoadSound is nil, don't play the sound
-				if c.LoadSound == nil {
-					motion.LoadedSound, err = disabled.LoadSound(soundPath)
-				} else {
-					motion.LoadedSound, err = c.LoadSound(soundPath)
-				}
-				if err != nil {
-					return
-				}
+			refs[name] = append(refs[name], motionRef{
+				path:        filepath.Join(dir, motion.File),
+				fadeInTime:  motion.FadeInTime,
+				fadeOutTime: motion.FadeOutTime,
+				sound:       motion.Sound,
+			})
+		}
+	}
+	loadSound := c.LoadSound
+	if loadSound == nil || opt.skipSounds {
+		// Don't play the sound
+		loadSound = disabled.LoadSound
+	}
+	load := func(ref motionRef) (mtn motion.Motion, err error) {
+		mtn, err = loadMotion(dir, ref, loadSound)
+		var loadErr *LoadError
+		if errors.As(err, &loadErr) && loadErr.Kind == FileSound {
+			if err = opt.tolerate(m, err); err == nil {
+				// Keep the motion without the sound
+				mtn.LoadedSound, _ = disabled.LoadSound(loadErr.Path)
 			}
-			m.motions[name] = append(m.motions[name], motion)
+		}
+		return
+	}
+	m.motions = newMotionStore(refs, load, opt.lazyMotions, opt.motionCacheLimit)
+	m.motions.inUse = m.isMotionPlaying
+	if !opt.lazyMotions {
+		// Drop the motions which cannot be loaded in lenient mode
+		if err = m.motions.loadAll(func(err error) error { return opt.tolerate(m, err) }); err != nil {
+			return
 		}
 	}
 
 	// Load user data if it exists
 	if mj.FileReferences.UserData != "" {
 		userDataPath := filepath.Join(dir, mj.FileReferences.UserData)
-		buf, err = os.ReadFile(userDataPath)
-		if err != nil {
-			return
+		if err = loadJson(FileUserData, userDataPath, &m.userdata); err != nil {
+			if err = opt.tolerate(m, err); err != nil {
+				return
+			}
+			m.userdata = model.UserDataJson{}
 		}
-		if err = json.Unmarshal(buf, &m.userdata); err != nil {
-			return
+	}
+
+	// Prepare the motion layers
+	m.parameterStore = internalmotion.NewParameterStore(c.core, m.moc.ModelPtr)
+	m.motionLayers = []*motionLayer{m.newMotionLayer(BaseMotionLayer, MotionBlendOverride)}
+
+	c.lifecycle.add(m)
+	return
+}
+
+// Load a motion and its sound
+func loadMotion(dir string, ref motionRef, loadSound func(fp string) (sound.Sound, error)) (mtn motion.Motion, err error) {
+	var mtnJson model.MotionJson
+	if err = loadJson(FileMotion, ref.path, &mtnJson); err != nil {
+		return
+	}
+	fp := filepath.Base(ref.path)
+	mtn, err = mtnJson.ToMotion(fp, ref.fadeInTime, ref.fadeOutTime, ref.sound)
+	if err != nil {
+		err = &LoadError{Kind: FileMotion, Path: ref.path, Err: err}
+		return
+	}
+	if mtn.Sound != "" {
+		soundPath := filepath.Join(dir, mtn.Sound)
+		mtn.LoadedSound, err = loadSound(soundPath)
+		if err != nil {
+			err = &LoadError{Kind: FileSound, Path: soundPath, Err: err}
 		}
 	}
+	return
+}
 
+// Read a json file into v
+// The error is returned as [*LoadError]
+func loadJson(kind FileKind, path string, v any) (err error) {
+	buf, err := os.ReadFile(path)
+	if err == nil {
+		err = json.Unmarshal(buf, v)
+	}
+	if err != nil {
+		err = &LoadError{Kind: kind, Path: path, Err: err}
+	}
 	return
 }
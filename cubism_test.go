@@ -0,0 +1,216 @@
+package cubism_test
+
+import (
+	"context"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aethiopicuschan/cubism-go"
+	"github.com/aethiopicuschan/cubism-go/core"
+	"github.com/aethiopicuschan/cubism-go/core/fake"
+	"github.com/aethiopicuschan/cubism-go/core/moc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func loadFixture(t testing.TB) *cubism.Model {
+	t.Helper()
+	path, err := fake.WriteFixture(t.TempDir())
+	require.NoError(t, err)
+	csm := cubism.NewCubismFromCore(fake.NewCore(fake.FixtureModel()))
+	m, err := csm.LoadModel(path)
+	require.NoError(t, err)
+	return m
+}
+
+func TestLoadModel(t *testing.T) {
+	t.Parallel()
+	m := loadFixture(t)
+
+	assert.Equal(t, 3, m.GetVersion())
+	assert.Len(t, m.GetTextures(), 1)
+	assert.Len(t, m.GetDrawables(), 6)
+	assert.Equal(t, []int{0, 1, 2, 3, 4, 5}, m.GetSortedIndices())
+	assert.Equal(t, []cubism.HitArea{{Id: "HitAreaBody", Name: "Body"}}, m.GetHitAreas())
+	assert.ElementsMatch(t, []string{"Idle", "TapBody", "Greeting"}, m.GetMotionGroupNames())
+	assert.Len(t, m.GetMotions("Idle"), 1)
+
+	d, err := m.GetDrawable("Mouth")
+	require.NoError(t, err)
+	assert.Equal(t, []int32{0}, d.Masks)
+	_, err = m.GetDrawable("Unknown")
+	assert.ErrorIs(t, err, cubism.ErrDrawableNotFound)
+}
+
+// A core with only the methods of [core.Core], hiding the optional ones
+type baseCore struct {
+	core.Core
+}
+
+func TestLoadModelBaseCore(t *testing.T) {
+	t.Parallel()
+	path, err := fake.WriteFixture(t.TempDir())
+	require.NoError(t, err)
+	csm := cubism.NewCubismFromCore(baseCore{fake.NewCore(fake.FixtureModel())})
+	m, err := csm.LoadModel(path)
+	require.NoError(t, err)
+
+	// The model works without the optional capabilities of the core
+	m.Update(0.1)
+	assert.Equal(t, []int{0, 1, 2, 3, 4, 5}, m.GetSortedIndices())
+	assert.Len(t, m.GetParts(), 5)
+	assert.Equal(t, float32(1), m.GetPartOpacity("PartArmA"))
+	assert.NoError(t, csm.Close())
+}
+
+func TestLoadModelMissing(t *testing.T) {
+	t.Parallel()
+	csm := cubism.NewCubismFromCore(fake.NewCore(fake.FixtureModel()))
+	_, err := csm.LoadModel("missing.model3.json")
+	assert.Error(t, err)
+}
+
+// A slog.Handler recording the messages
+type recordHandler struct {
+	slog.Handler
+	messages *[]string
+}
+
+func (h recordHandler) Handle(_ context.Context, r slog.Record) error {
+	*h.messages = append(*h.messages, r.Message)
+	return nil
+}
+
+func TestSetLogger(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	path, err := fake.WriteFixture(dir)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "Fake.moc3"), []byte("broken"), 0o644))
+
+	messages := []string{}
+	csm := cubism.NewCubismFromCore(fake.NewCore(fake.FixtureModel()))
+	csm.SetLogger(slog.New(recordHandler{Handler: slog.Default().Handler(), messages: &messages}))
+	_, err = csm.LoadModel(path)
+	assert.Error(t, err)
+	assert.Equal(t, []string{"[CSM] [E]csmHasMocConsistency: File is not a moc3 file."}, messages)
+
+	// The messages are discarded after removing the logger
+	csm.SetLogger(nil)
+	_, err = csm.LoadModel(path)
+	assert.Error(t, err)
+	assert.Len(t, messages, 1)
+}
+
+func TestLoadModelMocTooNew(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	path, err := fake.WriteFixture(dir)
+	require.NoError(t, err)
+	mocPath := filepath.Join(dir, "Fake.moc3")
+	require.NoError(t, os.WriteFile(mocPath, fake.Moc3(6), 0o644))
+
+	csm := cubism.NewCubismFromCore(fake.NewCore(fake.FixtureModel()))
+	v, err := csm.GetMocVersion(mocPath)
+	require.NoError(t, err)
+	assert.Equal(t, moc.Version(6), v)
+	assert.Equal(t, moc.Version50, csm.GetLatestMocVersion())
+
+	_, err = csm.LoadModel(path)
+	var tooNew *moc.ErrMocTooNew
+	require.ErrorAs(t, err, &tooNew)
+	assert.Equal(t, moc.Version(6), tooNew.Version)
+	assert.Equal(t, moc.Version50, tooNew.Latest)
+}
+
+func TestLoadModelError(t *testing.T) {
+	testcases := []struct {
+		name   string
+		file   string
+		broken bool
+		// Written instead of the file if set
+		content string
+		kind    cubism.FileKind
+		is      error
+	}{
+		{
+			name: "moc",
+			file: "Fake.moc3",
+			kind: cubism.FileMoc,
+			is:   fs.ErrNotExist,
+		},
+		{
+			name:   "invalid moc",
+			file:   "Fake.moc3",
+			broken: true,
+			kind:   cubism.FileMoc,
+			is:     moc.ErrInvalidMoc,
+		},
+		{
+			name: "physics",
+			file: "Fake.physics3.json",
+			kind: cubism.FilePhysics,
+			is:   fs.ErrNotExist,
+		},
+		{
+			name:   "pose",
+			file:   "Fake.pose3.json",
+			broken: true,
+			kind:   cubism.FilePose,
+		},
+		{
+			name: "expression",
+			file: filepath.Join("expressions", "Smile.exp3.json"),
+			kind: cubism.FileExpression,
+			is:   fs.ErrNotExist,
+		},
+		{
+			name:   "motion",
+			file:   filepath.Join("motions", "Tap.motion3.json"),
+			broken: true,
+			kind:   cubism.FileMotion,
+		},
+		{
+			name:    "invalid motion",
+			file:    filepath.Join("motions", "Tap.motion3.json"),
+			content: `{"Curves": [{"Id": "ParamAngleX", "Segments": [0, 0, 9, 1, 1]}]}`,
+			kind:    cubism.FileMotion,
+		},
+		{
+			name: "user data",
+			file: "Fake.userdata3.json",
+			kind: cubism.FileUserData,
+			is:   fs.ErrNotExist,
+		},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.name, func(t *testing.T) {
+			t.Parallel()
+			dir := t.TempDir()
+			path, err := fake.WriteFixture(dir)
+			require.NoError(t, err)
+			target := filepath.Join(dir, testcase.file)
+			if testcase.content != "" {
+				require.NoError(t, os.WriteFile(target, []byte(testcase.content), 0o644))
+			} else if testcase.broken {
+				require.NoError(t, os.WriteFile(target, []byte("broken"), 0o644))
+			} else {
+				require.NoError(t, os.Remove(target))
+			}
+
+			csm := cubism.NewCubismFromCore(fake.NewCore(fake.FixtureModel()))
+			_, err = csm.LoadModel(path)
+			var loadErr *cubism.LoadError
+			require.ErrorAs(t, err, &loadErr)
+			assert.Equal(t, testcase.kind, loadErr.Kind)
+			assert.Equal(t, target, loadErr.Path)
+			if testcase.is != nil {
+				assert.ErrorIs(t, err, testcase.is)
+			}
+		})
+	}
+}
@@ -1,6 +1,6 @@
 package cubism
 
-import "github.com/aethiopicuschan/cubism-go/internal/core/drawable"
+import "github.com/aethiopicuschan/cubism-go/core/drawable"
 
 type Drawable struct {
 	Id              string
@@ -12,4 +12,6 @@ type Drawable struct {
 	DynamicFlag     drawable.DynamicFlag
 	Opacity         float32
 	Masks           []int32
+	// Index of the parent part, -1 if the drawable is at the root
+	Parent int
 }
@@ -0,0 +1,83 @@
+package cubism
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// The model or the Cubism has already been closed
+	ErrClosed = errors.New("already closed")
+	// The Drawable with the specified ID does not exist
+	ErrDrawableNotFound = errors.New("drawable not found")
+	// The parameter with the specified ID does not exist
+	ErrParameterNotFound = errors.New("parameter not found")
+	// The part with the specified ID does not exist
+	ErrPartNotFound = errors.New("part not found")
+	// The motion with the specified ID is not playing
+	ErrMotionNotFound = errors.New("motion not found")
+	// The motion group with the specified name does not exist
+	ErrMotionGroupNotFound = errors.New("motion group not found")
+	// The index is out of the range of the motion group
+	ErrMotionIndexOutOfRange = errors.New("motion index out of range")
+	// The motion layer with the specified name does not exist
+	ErrMotionLayerNotFound = errors.New("motion layer not found")
+	// A motion layer with the specified name already exists
+	ErrMotionLayerExists = errors.New("motion layer already exists")
+)
+
+// Kinds of files making up a model
+type FileKind int
+
+const (
+	FileModel FileKind = iota
+	FileMoc
+	FilePhysics
+	FilePose
+	FileDisplayInfo
+	FileExpression
+	FileMotion
+	FileSound
+	FileUserData
+)
+
+func (k FileKind) String() string {
+	switch k {
+	case FileModel:
+		return "model"
+	case FileMoc:
+		return "moc"
+	case FilePhysics:
+		return "physics"
+	case FilePose:
+		return "pose"
+	case FileDisplayInfo:
+		return "display info"
+	case FileExpression:
+		return "expression"
+	case FileMotion:
+		return "motion"
+	case FileSound:
+		return "sound"
+	case FileUserData:
+		return "user data"
+	}
+	return fmt.Sprintf("unknown (%d)", int(k))
+}
+
+// Error returned when a file of a model cannot be loaded
+// The cause can be inspected with [errors.Is] and [errors.As]
+type LoadError struct {
+	Kind FileKind
+	// Path of the file
+	Path string
+	Err  error
+}
+
+func (e *LoadError) Error() string {
+	return fmt.Sprintf("failed to load %s %s: %v", e.Kind, e.Path, e.Err)
+}
+
+func (e *LoadError) Unwrap() error {
+	return e.Err
+}
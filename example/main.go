@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"image/color"
 	"log"
+	"log/slog"
 
 	"github.com/aethiopicuschan/cubism-go"
 	renderer "github.com/aethiopicuschan/cubism-go/renderer/ebitengine"
@@ -49,7 +50,10 @@ func (g *Game) Update() (err error) {
 		ebiten.SetCursorShape(ebiten.CursorShapePointer)
 		if inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) {
 			g.renderer.GetModel().StopMotion(g.tapId)
-			g.tapId = g.renderer.GetModel().PlayMotion("TapBody", 0, false)
+			g.tapId, err = g.renderer.GetModel().PlayMotion("TapBody", 0, false)
+			if err != nil {
+				return
+			}
 		}
 	} else if ebiten.CursorShape() == ebiten.CursorShapePointer {
 		ebiten.SetCursorShape(ebiten.CursorShapeDefault)
@@ -73,6 +77,8 @@ func main() {
 	if err != nil {
 		log.Fatal(err)
 	}
+	// Report the messages from the core
+	csm.SetLogger(slog.Default())
 	// Set function for playing sound
 	csm.LoadSound = normal.LoadSound
 	model, err := csm.LoadModel(fmt.Sprintf("Resources/%s/%s.model3.json", Name, Name))
@@ -80,7 +86,9 @@ func main() {
 		log.Fatal(err)
 	}
 	// Play idle motion
-	model.PlayMotion("Idle", 0, true)
+	if _, err := model.PlayMotion("Idle", 0, true); err != nil {
+		log.Fatal(err)
+	}
 	renderer, err := renderer.NewRenderer(model)
 	if err != nil {
 		log.Fatal(err)
@@ -0,0 +1,8 @@
+package cubism
+
+// A hit area defined in model3.json
+type HitArea struct {
+	// ID of the Drawable used for collision detection
+	Id   string
+	Name string
+}
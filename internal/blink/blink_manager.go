@@ -3,7 +3,7 @@ package blink
 import (
 	"math/rand"
 
-	"github.com/aethiopicuschan/cubism-go/internal/core"
+	"github.com/aethiopicuschan/cubism-go/core"
 )
 
 const (
@@ -91,3 +91,29 @@ func (b *BlinkManager) Update(delta float64) {
 		b.core.SetParameterValue(b.modelPtr, id, value)
 	}
 }
+
+// Progress of the blinking
+type State struct {
+	State            int
+	CurrentTime      float64
+	StateStartTime   float64
+	NextBlinkingTime float64
+}
+
+// Get the progress of the blinking
+func (b *BlinkManager) GetState() State {
+	return State{
+		State:            b.state,
+		CurrentTime:      b.currentTime,
+		StateStartTime:   b.stateStartTime,
+		NextBlinkingTime: b.nextBlinkingTime,
+	}
+}
+
+// Continue the blinking from the progress
+func (b *BlinkManager) SetState(s State) {
+	b.state = s.State
+	b.currentTime = s.CurrentTime
+	b.stateStartTime = s.StateStartTime
+	b.nextBlinkingTime = s.NextBlinkingTime
+}
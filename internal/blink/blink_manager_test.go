@@ -0,0 +1,29 @@
+package blink_test
+
+import (
+	"testing"
+
+	"github.com/aethiopicuschan/cubism-go/core/fake"
+	"github.com/aethiopicuschan/cubism-go/internal/blink"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBlinkManager(t *testing.T) {
+	t.Parallel()
+	c, m := fake.LoadFixture(t)
+
+	b := blink.NewBlinkManager(c, m.ModelPtr, []string{"ParamEyeLOpen", "ParamEyeROpen"})
+	minimum := float32(1)
+	for i := 0; i < 60*10; i++ {
+		b.Update(1.0 / 60)
+		l := c.GetParameterValue(m.ModelPtr, "ParamEyeLOpen")
+		r := c.GetParameterValue(m.ModelPtr, "ParamEyeROpen")
+		assert.Equal(t, l, r)
+		assert.LessOrEqual(t, l, float32(1))
+		if l < minimum {
+			minimum = l
+		}
+	}
+	// The eyes are closed at least once in ten seconds
+	assert.LessOrEqual(t, minimum, float32(0))
+}
@@ -0,0 +1,56 @@
+package core
+
+import (
+	"unsafe"
+
+	"github.com/aethiopicuschan/cubism-go/core/drawable"
+	"github.com/aethiopicuschan/cubism-go/internal/strings"
+)
+
+// Data which stays the same for the lifetime of a model
+// It is built on first use so that the hot path does not convert the IDs on each call
+type modelCache struct {
+	parameterIndices map[string]int
+	partIndices      map[string]int
+	// The arrays of the core are allocated once per model, so the views stay valid
+	vertexPositions [][]drawable.Vector2
+}
+
+func (c *Core) cache(modelPtr uintptr) *modelCache {
+	c.cacheMu.Lock()
+	defer c.cacheMu.Unlock()
+	if mc, ok := c.caches[modelPtr]; ok {
+		return mc
+	}
+	mc := &modelCache{
+		parameterIndices: make(map[string]int),
+		partIndices:      make(map[string]int),
+	}
+	count := c.csmGetParameterCount(modelPtr)
+	idsPtr := c.csmGetParameterIds(modelPtr)
+	for i := 0; i < count; i++ {
+		ptr := *(**byte)(unsafe.Pointer(idsPtr + uintptr(i)*unsafe.Sizeof(uintptr(0))))
+		mc.parameterIndices[strings.GoString(uintptr(unsafe.Pointer(ptr)))] = i
+	}
+	for i, id := range c.GetPartIds(modelPtr) {
+		mc.partIndices[id] = i
+	}
+	count = c.csmGetDrawableCount(modelPtr)
+	vertexCounts := unsafe.Slice((*int32)(unsafe.Pointer(c.csmGetDrawableVertexCounts(modelPtr))), count)
+	posPtr := c.csmGetDrawableVertexPositions(modelPtr)
+	for i := 0; i < count; i++ {
+		positions := unsafe.Slice(*(**drawable.Vector2)(unsafe.Pointer(posPtr + uintptr(i)*unsafe.Sizeof(uintptr(0)))), int(vertexCounts[i]))
+		mc.vertexPositions = append(mc.vertexPositions, positions)
+	}
+	if c.caches == nil {
+		c.caches = make(map[uintptr]*modelCache)
+	}
+	c.caches[modelPtr] = mc
+	return mc
+}
+
+func (c *Core) releaseCache(modelPtr uintptr) {
+	c.cacheMu.Lock()
+	defer c.cacheMu.Unlock()
+	delete(c.caches, modelPtr)
+}
@@ -3,56 +3,73 @@ package core
 import (
 	"fmt"
 	"os"
+	"sync"
 	"unsafe"
 
-	"github.com/aethiopicuschan/cubism-go/internal/core/drawable"
-	"github.com/aethiopicuschan/cubism-go/internal/core/moc"
-	"github.com/aethiopicuschan/cubism-go/internal/core/parameter"
+	"github.com/aethiopicuschan/cubism-go/core/drawable"
+	mocpkg "github.com/aethiopicuschan/cubism-go/core/moc"
+	"github.com/aethiopicuschan/cubism-go/core/parameter"
+	"github.com/aethiopicuschan/cubism-go/core/part"
 	"github.com/aethiopicuschan/cubism-go/internal/strings"
 	"github.com/aethiopicuschan/cubism-go/internal/utils"
 	"github.com/ebitengine/purego"
 )
 
 type Core struct {
-	lib                           uintptr
-	csmGetVersion                 func() uint32
-	csmReviveMocInPlace           func(uintptr, uint) uintptr
-	csmGetSizeofModel             func(uintptr) uint
-	csmInitializeModelInPlace     func(uintptr, uintptr, uint) uintptr
-	csmUpdateModel                func(uintptr)
-	csmReadCanvasInfo             func(uintptr, uintptr, uintptr, uintptr)
-	csmGetParameterCount          func(uintptr) int
-	csmGetParameterIds            func(uintptr) uintptr
-	csmGetParameterTypes          func(uintptr) uintptr
-	csmGetParameterMinimumValues  func(uintptr) uintptr
-	csmGetParameterMaximumValues  func(uintptr) uintptr
-	csmGetParameterDefaultValues  func(uintptr) uintptr
-	csmGetParameterValues         func(uintptr) uintptr
-	csmGetPartCount               func(uintptr) int
-	csmGetPartIds                 func(uintptr) uintptr
-	csmGetPartOpacities           func(uintptr) uintptr
-	csmGetDrawableCount           func(uintptr) int
-	csmGetDrawableIds             func(uintptr) uintptr
-	csmGetDrawableConstantFlags   func(uintptr) uintptr
-	csmGetDrawableDynamicFlags    func(uintptr) uintptr
-	csmGetDrawableTextureIndices  func(uintptr) uintptr
-	csmGetDrawableRenderOrders    func(uintptr) uintptr
-	csmGetDrawableOpacities       func(uintptr) uintptr
-	csmGetDrawableMaskCounts      func(uintptr) uintptr
-	csmGetDrawableMasks           func(uintptr) uintptr
-	csmGetDrawableVertexCounts    func(uintptr) uintptr
-	csmGetDrawableVertexPositions func(uintptr) uintptr
-	csmGetDrawableVertexUvs       func(uintptr) uintptr
-	csmGetDrawableIndexCounts     func(uintptr) uintptr
-	csmGetDrawableIndices         func(uintptr) uintptr
-	csmResetDrawableDynamicFlags  func(uintptr)
-	csmHasMocConsistency          func(uintptr, uint) int
+	lib                             uintptr
+	logMu                           sync.Mutex
+	logCallback                     uintptr
+	logFunction                     func(string)
+	cacheMu                         sync.Mutex
+	caches                          map[uintptr]*modelCache
+	csmGetVersion                   func() uint32
+	csmGetLatestMocVersion          func() uint32
+	csmGetMocVersion                func(uintptr, uint) uint32
+	csmReviveMocInPlace             func(uintptr, uint) uintptr
+	csmGetSizeofModel               func(uintptr) uint
+	csmInitializeModelInPlace       func(uintptr, uintptr, uint) uintptr
+	csmUpdateModel                  func(uintptr)
+	csmReadCanvasInfo               func(uintptr, uintptr, uintptr, uintptr)
+	csmGetParameterCount            func(uintptr) int
+	csmGetParameterIds              func(uintptr) uintptr
+	csmGetParameterTypes            func(uintptr) uintptr
+	csmGetParameterMinimumValues    func(uintptr) uintptr
+	csmGetParameterMaximumValues    func(uintptr) uintptr
+	csmGetParameterDefaultValues    func(uintptr) uintptr
+	csmGetParameterValues           func(uintptr) uintptr
+	csmGetParameterRepeats          func(uintptr) uintptr
+	csmGetParameterKeyCounts        func(uintptr) uintptr
+	csmGetParameterKeyValues        func(uintptr) uintptr
+	csmGetPartCount                 func(uintptr) int
+	csmGetPartIds                   func(uintptr) uintptr
+	csmGetPartOpacities             func(uintptr) uintptr
+	csmGetPartParentPartIndices     func(uintptr) uintptr
+	csmGetDrawableCount             func(uintptr) int
+	csmGetDrawableIds               func(uintptr) uintptr
+	csmGetDrawableConstantFlags     func(uintptr) uintptr
+	csmGetDrawableDynamicFlags      func(uintptr) uintptr
+	csmGetDrawableTextureIndices    func(uintptr) uintptr
+	csmGetDrawableRenderOrders      func(uintptr) uintptr
+	csmGetDrawableOpacities         func(uintptr) uintptr
+	csmGetDrawableMaskCounts        func(uintptr) uintptr
+	csmGetDrawableMasks             func(uintptr) uintptr
+	csmGetDrawableVertexCounts      func(uintptr) uintptr
+	csmGetDrawableVertexPositions   func(uintptr) uintptr
+	csmGetDrawableVertexUvs         func(uintptr) uintptr
+	csmGetDrawableIndexCounts       func(uintptr) uintptr
+	csmGetDrawableIndices           func(uintptr) uintptr
+	csmGetDrawableParentPartIndices func(uintptr) uintptr
+	csmResetDrawableDynamicFlags    func(uintptr)
+	csmSetLogFunction               func(uintptr)
+	csmHasMocConsistency            func(uintptr, uint) int
 }
 
 func NewCore(lib uintptr) (c *Core, err error) {
 	c = new(Core)
 	c.lib = lib
 	purego.RegisterLibFunc(&c.csmGetVersion, lib, "csmGetVersion")
+	purego.RegisterLibFunc(&c.csmGetLatestMocVersion, lib, "csmGetLatestMocVersion")
+	purego.RegisterLibFunc(&c.csmGetMocVersion, lib, "csmGetMocVersion")
 	purego.RegisterLibFunc(&c.csmReviveMocInPlace, lib, "csmReviveMocInPlace")
 	purego.RegisterLibFunc(&c.csmGetSizeofModel, lib, "csmGetSizeofModel")
 	purego.RegisterLibFunc(&c.csmInitializeModelInPlace, lib, "csmInitializeModelInPlace")
@@ -65,9 +82,18 @@ func NewCore(lib uintptr) (c *Core, err error) {
 	purego.RegisterLibFunc(&c.csmGetParameterMaximumValues, lib, "csmGetParameterMaximumValues")
 	purego.RegisterLibFunc(&c.csmGetParameterDefaultValues, lib, "csmGetParameterDefaultValues")
 	purego.RegisterLibFunc(&c.csmGetParameterValues, lib, "csmGetParameterValues")
+	// The repeats and the keys of the parameters are only exported by newer builds of the core
+	if hasSymbol(lib, "csmGetParameterRepeats") {
+		purego.RegisterLibFunc(&c.csmGetParameterRepeats, lib, "csmGetParameterRepeats")
+	}
+	if hasSymbol(lib, "csmGetParameterKeyCounts") && hasSymbol(lib, "csmGetParameterKeyValues") {
+		purego.RegisterLibFunc(&c.csmGetParameterKeyCounts, lib, "csmGetParameterKeyCounts")
+		purego.RegisterLibFunc(&c.csmGetParameterKeyValues, lib, "csmGetParameterKeyValues")
+	}
 	purego.RegisterLibFunc(&c.csmGetPartCount, lib, "csmGetPartCount")
 	purego.RegisterLibFunc(&c.csmGetPartIds, lib, "csmGetPartIds")
 	purego.RegisterLibFunc(&c.csmGetPartOpacities, lib, "csmGetPartOpacities")
+	purego.RegisterLibFunc(&c.csmGetPartParentPartIndices, lib, "csmGetPartParentPartIndices")
 	purego.RegisterLibFunc(&c.csmGetDrawableCount, lib, "csmGetDrawableCount")
 	purego.RegisterLibFunc(&c.csmGetDrawableIds, lib, "csmGetDrawableIds")
 	purego.RegisterLibFunc(&c.csmGetDrawableConstantFlags, lib, "csmGetDrawableConstantFlags")
@@ -82,22 +108,36 @@ func NewCore(lib uintptr) (c *Core, err error) {
 	purego.RegisterLibFunc(&c.csmGetDrawableVertexUvs, lib, "csmGetDrawableVertexUvs")
 	purego.RegisterLibFunc(&c.csmGetDrawableIndexCounts, lib, "csmGetDrawableIndexCounts")
 	purego.RegisterLibFunc(&c.csmGetDrawableIndices, lib, "csmGetDrawableIndices")
+	purego.RegisterLibFunc(&c.csmGetDrawableParentPartIndices, lib, "csmGetDrawableParentPartIndices")
 	purego.RegisterLibFunc(&c.csmResetDrawableDynamicFlags, lib, "csmResetDrawableDynamicFlags")
 	purego.RegisterLibFunc(&c.csmHasMocConsistency, lib, "csmHasMocConsistency")
+	purego.RegisterLibFunc(&c.csmSetLogFunction, lib, "csmSetLogFunction")
 	return
 }
 
 // Load moc3 and return moc.Moc
-func (c *Core) LoadMoc(path string) (moc moc.Moc, err error) {
+func (c *Core) LoadMoc(path string) (moc mocpkg.Moc, err error) {
 	// Read the moc3
 	moc.MocBuffer, err = os.ReadFile(path)
 	if err != nil {
 		return
 	}
+	// Check the version before the consistency so that newer files are reported as such
+	moc.Version, err = mocpkg.ReadVersion(moc.MocBuffer)
+	if err != nil {
+		return
+	}
+	if latest := c.GetLatestMocVersion(); moc.Version > latest {
+		err = &mocpkg.ErrMocTooNew{
+			Version: moc.Version,
+			Latest:  latest,
+		}
+		return
+	}
 	// Check the consistency
 	consistency := c.csmHasMocConsistency(uintptr(unsafe.Pointer(&moc.MocBuffer[0])), uint(len(moc.MocBuffer)))
 	if consistency != 1 {
-		err = fmt.Errorf("moc3 is not consistent")
+		err = mocpkg.ErrInconsistentMoc
 		return
 	}
 	// Load the moc3
@@ -123,6 +163,62 @@ func (c *Core) LoadMoc(path string) (moc moc.Moc, err error) {
 	return
 }
 
+// Set the function receiving the log messages of the core
+// Passing nil discards the messages
+func (c *Core) SetLogFunction(f func(string)) {
+	c.logMu.Lock()
+	defer c.logMu.Unlock()
+	c.logFunction = f
+	// The number of callbacks is limited, so only one is created for each core
+	if c.logCallback == 0 {
+		c.logCallback = purego.NewCallback(func(message uintptr) {
+			c.logMu.Lock()
+			f := c.logFunction
+			c.logMu.Unlock()
+			if f != nil {
+				f(strings.GoString(message))
+			}
+		})
+		c.csmSetLogFunction(c.logCallback)
+	}
+}
+
+// Release the model
+// The buffers are owned by Go, so it is enough to drop the cached data referring to them
+func (c *Core) ReleaseMoc(moc mocpkg.Moc) {
+	c.releaseCache(moc.ModelPtr)
+}
+
+// Close the library
+func (c *Core) Close() (err error) {
+	if c.lib == 0 {
+		return
+	}
+	// Make sure that the core does not call the log function after the library is gone
+	if c.logCallback != 0 {
+		c.csmSetLogFunction(0)
+	}
+	err = closeLibrary(c.lib)
+	c.lib = 0
+	c.cacheMu.Lock()
+	c.caches = nil
+	c.cacheMu.Unlock()
+	return
+}
+
+// Get the latest moc3 version supported by the core
+func (c *Core) GetLatestMocVersion() mocpkg.Version {
+	return mocpkg.Version(c.csmGetLatestMocVersion())
+}
+
+// Get the version of moc3
+func (c *Core) GetMocVersion(buf []byte) mocpkg.Version {
+	if len(buf) == 0 {
+		return mocpkg.VersionUnknown
+	}
+	return mocpkg.Version(c.csmGetMocVersion(uintptr(unsafe.Pointer(&buf[0])), uint(len(buf))))
+}
+
 // Get version
 func (c *Core) GetVersion() string {
 	raw := c.csmGetVersion()
@@ -139,6 +235,12 @@ func (c *Core) GetDynamicFlags(modelPtr uintptr) (rs []drawable.DynamicFlag) {
 	return
 }
 
+// Get the packed dynamic flags without copying
+func (c *Core) GetDynamicFlagBits(modelPtr uintptr) []uint8 {
+	count := c.csmGetDrawableCount(modelPtr)
+	return unsafe.Slice((*uint8)(unsafe.Pointer(c.csmGetDrawableDynamicFlags(modelPtr))), count)
+}
+
 // Get opacities
 func (c *Core) GetOpacities(modelPtr uintptr) (rs []float32) {
 	count := c.csmGetDrawableCount(modelPtr)
@@ -148,20 +250,12 @@ func (c *Core) GetOpacities(modelPtr uintptr) (rs []float32) {
 
 // Get vertex positions
 func (c *Core) GetVertexPositions(modelPtr uintptr) (vps [][]drawable.Vector2) {
-	count := c.csmGetDrawableCount(modelPtr)
-	// 頂点の数
-	vertexCounts := unsafe.Slice((*int32)(unsafe.Pointer(c.csmGetDrawableVertexCounts(modelPtr))), count)
-	posPtr := c.csmGetDrawableVertexPositions(modelPtr)
-	for i := 0; i < count; i++ {
-		vertexCount := vertexCounts[i]
-		positions := unsafe.Slice(*(**drawable.Vector2)(unsafe.Pointer(posPtr + uintptr(i)*unsafe.Sizeof(uintptr(0)))), int(vertexCount))
-		vps = append(vps, positions)
-	}
-	return
+	return c.cache(modelPtr).vertexPositions
 }
 
 // Get Drawables
 // Since all the information is gathered, the cost is high. It is expected to be called only once initially
+// Unlike the other getters, the data is copied into Go memory
 func (c *Core) GetDrawables(modelPtr uintptr) (ds []drawable.Drawable) {
 	count := c.csmGetDrawableCount(modelPtr)
 
@@ -186,9 +280,9 @@ func (c *Core) GetDrawables(modelPtr uintptr) (ds []drawable.Drawable) {
 	for i := 0; i < count; i++ {
 		vertexCount := vertexCounts[i]
 		positions := unsafe.Slice(*(**drawable.Vector2)(unsafe.Pointer(posPtr + uintptr(i)*unsafe.Sizeof(uintptr(0)))), int(vertexCount))
-		vertexPositions = append(vertexPositions, positions)
+		vertexPositions = append(vertexPositions, append([]drawable.Vector2(nil), positions...))
 		uvs := unsafe.Slice(*(**drawable.Vector2)(unsafe.Pointer(uvPtr + uintptr(i)*unsafe.Sizeof(uintptr(0)))), int(vertexCount))
-		vertexUvs = append(vertexUvs, uvs)
+		vertexUvs = append(vertexUvs, append([]drawable.Vector2(nil), uvs...))
 	}
 
 	// Size of the array of corresponding numbers for the polygon
@@ -198,7 +292,8 @@ func (c *Core) GetDrawables(modelPtr uintptr) (ds []drawable.Drawable) {
 	indicesPtr := c.csmGetDrawableIndices(modelPtr)
 	for i := 0; i < count; i++ {
 		indexCount := indexCounts[i]
-		indices = append(indices, unsafe.Slice(*(**uint16)(unsafe.Pointer(indicesPtr + uintptr(i)*unsafe.Sizeof(uintptr(0)))), int(indexCount)))
+		raw := unsafe.Slice(*(**uint16)(unsafe.Pointer(indicesPtr + uintptr(i)*unsafe.Sizeof(uintptr(0)))), int(indexCount))
+		indices = append(indices, append([]uint16(nil), raw...))
 	}
 
 	// Number of masks
@@ -208,9 +303,13 @@ func (c *Core) GetDrawables(modelPtr uintptr) (ds []drawable.Drawable) {
 	maskPtr := c.csmGetDrawableMasks(modelPtr)
 	for i := 0; i < count; i++ {
 		maskCount := maskCounts[i]
-		masks = append(masks, unsafe.Slice(*(**int32)(unsafe.Pointer(maskPtr + uintptr(i)*unsafe.Sizeof(uintptr(0)))), int(maskCount)))
+		raw := unsafe.Slice(*(**int32)(unsafe.Pointer(maskPtr + uintptr(i)*unsafe.Sizeof(uintptr(0)))), int(maskCount))
+		masks = append(masks, append([]int32(nil), raw...))
 	}
 
+	// Parent parts
+	parents := unsafe.Slice((*int32)(unsafe.Pointer(c.csmGetDrawableParentPartIndices(modelPtr))), count)
+
 	// ID
 	idsPtr := c.csmGetDrawableIds(modelPtr)
 	ids := make([]string, 0)
@@ -231,6 +330,7 @@ func (c *Core) GetDrawables(modelPtr uintptr) (ds []drawable.Drawable) {
 			DynamicFlag:     dynamicFlags[i],
 			Opacity:         opacities[i],
 			Masks:           masks[i],
+			Parent:          int(parents[i]),
 		}
 		ds = append(ds, d)
 	}
@@ -241,6 +341,8 @@ func (c *Core) GetDrawables(modelPtr uintptr) (ds []drawable.Drawable) {
 func (c *Core) GetParameters(modelPtr uintptr) (parameters []parameter.Parameter) {
 	count := c.csmGetParameterCount(modelPtr)
 	idsPtr := c.csmGetParameterIds(modelPtr)
+	typePtr := c.csmGetParameterTypes(modelPtr)
+	types := unsafe.Slice((*int32)(unsafe.Pointer(typePtr)), count)
 	minPtr := c.csmGetParameterMinimumValues(modelPtr)
 	mins := unsafe.Slice((*float32)(unsafe.Pointer(minPtr)), count)
 	maxPtr := c.csmGetParameterMaximumValues(modelPtr)
@@ -249,15 +351,36 @@ func (c *Core) GetParameters(modelPtr uintptr) (parameters []parameter.Parameter
 	defs := unsafe.Slice((*float32)(unsafe.Pointer(defPtr)), count)
 	valPtr := c.csmGetParameterValues(modelPtr)
 	vals := unsafe.Slice((*float32)(unsafe.Pointer(valPtr)), count)
+	var repeats []int32
+	if c.csmGetParameterRepeats != nil {
+		repeats = unsafe.Slice((*int32)(unsafe.Pointer(c.csmGetParameterRepeats(modelPtr))), count)
+	}
+	var keyCounts []int32
+	var keyValuesPtr uintptr
+	if c.csmGetParameterKeyCounts != nil {
+		keyCounts = unsafe.Slice((*int32)(unsafe.Pointer(c.csmGetParameterKeyCounts(modelPtr))), count)
+		keyValuesPtr = c.csmGetParameterKeyValues(modelPtr)
+	}
 	for i := 0; i < count; i++ {
 		ptr := *(**byte)(unsafe.Pointer(idsPtr + uintptr(i)*unsafe.Sizeof(uintptr(0))))
 		parameter := parameter.Parameter{
 			Id:      strings.GoString(uintptr(unsafe.Pointer(ptr))),
+			Type:    parameter.Type(types[i]),
 			Minimum: mins[i],
 			Maximum: maxs[i],
 			Default: defs[i],
 			Current: vals[i],
 		}
+		if repeats != nil {
+			parameter.Repeat = repeats[i] != 0
+		}
+		if keyCounts != nil {
+			// Copy the key values so that they stay valid independently of the model
+			parameter.KeyValues = make([]float32, keyCounts[i])
+			if keyCounts[i] > 0 {
+				copy(parameter.KeyValues, unsafe.Slice(*(**float32)(unsafe.Pointer(keyValuesPtr + uintptr(i)*unsafe.Sizeof(uintptr(0)))), int(keyCounts[i])))
+			}
+		}
 		parameters = append(parameters, parameter)
 	}
 	return
@@ -265,32 +388,20 @@ func (c *Core) GetParameters(modelPtr uintptr) (parameters []parameter.Parameter
 
 // Get parameter value
 func (c *Core) GetParameterValue(modelPtr uintptr, id string) float32 {
-	count := c.csmGetParameterCount(modelPtr)
-	idsPtr := c.csmGetParameterIds(modelPtr)
-	valPtr := c.csmGetParameterValues(modelPtr)
-	vals := unsafe.Slice((*float32)(unsafe.Pointer(valPtr)), count)
-	for i := 0; i < count; i++ {
-		ptr := *(**byte)(unsafe.Pointer(idsPtr + uintptr(i)*unsafe.Sizeof(uintptr(0))))
-		_id := strings.GoString(uintptr(unsafe.Pointer(ptr)))
-		if _id == id {
-			return vals[i]
-		}
+	i, ok := c.cache(modelPtr).parameterIndices[id]
+	if !ok {
+		return 0
 	}
-	return 0
+	return *(*float32)(unsafe.Pointer(c.csmGetParameterValues(modelPtr) + uintptr(i)*unsafe.Sizeof(float32(0))))
 }
 
 // Set parameter value
 func (c *Core) SetParameterValue(modelPtr uintptr, id string, value float32) {
-	count := c.csmGetParameterCount(modelPtr)
-	idsPtr := c.csmGetParameterIds(modelPtr)
-	valPtr := c.csmGetParameterValues(modelPtr)
-	for i := 0; i < count; i++ {
-		ptr := *(**byte)(unsafe.Pointer(idsPtr + uintptr(i)*unsafe.Sizeof(uintptr(0))))
-		if strings.GoString(uintptr(unsafe.Pointer(ptr))) == id {
-			*(*float32)(unsafe.Pointer(valPtr + uintptr(i)*unsafe.Sizeof(float32(0)))) = value
-			return
-		}
+	i, ok := c.cache(modelPtr).parameterIndices[id]
+	if !ok {
+		return
 	}
+	*(*float32)(unsafe.Pointer(c.csmGetParameterValues(modelPtr) + uintptr(i)*unsafe.Sizeof(float32(0)))) = value
 }
 
 // Get the part IDs
@@ -304,16 +415,45 @@ func (c *Core) GetPartIds(modelPtr uintptr) (ids []string) {
 	return
 }
 
+// Get the parts
+func (c *Core) GetParts(modelPtr uintptr) (parts []part.Part) {
+	count := c.csmGetPartCount(modelPtr)
+	ids := c.GetPartIds(modelPtr)
+	opacities := unsafe.Slice((*float32)(unsafe.Pointer(c.csmGetPartOpacities(modelPtr))), count)
+	parents := unsafe.Slice((*int32)(unsafe.Pointer(c.csmGetPartParentPartIndices(modelPtr))), count)
+	for i := 0; i < count; i++ {
+		parts = append(parts, part.Part{
+			Id:      ids[i],
+			Opacity: opacities[i],
+			Parent:  int(parents[i]),
+		})
+	}
+	return
+}
+
+// Get the part's opacity
+func (c *Core) GetPartOpacity(modelPtr uintptr, id string) float32 {
+	i, ok := c.cache(modelPtr).partIndices[id]
+	if !ok {
+		return 0
+	}
+	return *(*float32)(unsafe.Pointer(c.csmGetPartOpacities(modelPtr) + uintptr(i)*unsafe.Sizeof(float32(0))))
+}
+
 // Set the part's opacity
 func (c *Core) SetPartOpacity(modelPtr uintptr, id string, value float32) {
-	ids := c.GetPartIds(modelPtr)
-	ptr := c.csmGetPartOpacities(modelPtr)
-	for i, _id := range ids {
-		if _id == id {
-			*(*float32)(unsafe.Pointer(ptr + uintptr(i)*unsafe.Sizeof(float32(0)))) = value
-			return
-		}
+	i, ok := c.cache(modelPtr).partIndices[id]
+	if !ok {
+		return
 	}
+	*(*float32)(unsafe.Pointer(c.csmGetPartOpacities(modelPtr) + uintptr(i)*unsafe.Sizeof(float32(0)))) = value
+}
+
+// Get the render orders without copying
+// The n-th value is the position of the n-th drawable in the drawing order
+func (c *Core) GetRenderOrders(modelPtr uintptr) []int32 {
+	count := c.csmGetDrawableCount(modelPtr)
+	return unsafe.Slice((*int32)(unsafe.Pointer(c.csmGetDrawableRenderOrders(modelPtr))), count)
 }
 
 // Get the drawing order
@@ -0,0 +1,16 @@
+//go:build darwin || freebsd || linux
+
+package core
+
+import "github.com/ebitengine/purego"
+
+// Check whether the library exports the symbol
+func hasSymbol(lib uintptr, name string) bool {
+	ptr, err := purego.Dlsym(lib, name)
+	return err == nil && ptr != 0
+}
+
+// Close the library
+func closeLibrary(lib uintptr) error {
+	return purego.Dlclose(lib)
+}
@@ -0,0 +1,16 @@
+//go:build windows
+
+package core
+
+import "golang.org/x/sys/windows"
+
+// Check whether the library exports the symbol
+func hasSymbol(lib uintptr, name string) bool {
+	ptr, err := windows.GetProcAddress(windows.Handle(lib), name)
+	return err == nil && ptr != 0
+}
+
+// Close the library
+func closeLibrary(lib uintptr) error {
+	return windows.FreeLibrary(windows.Handle(lib))
+}
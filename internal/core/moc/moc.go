@@ -1,8 +0,0 @@
-package moc
-
-type Moc struct {
-	MocPtr      uintptr
-	MocBuffer   []byte
-	ModelPtr    uintptr
-	ModelBuffer []byte
-}
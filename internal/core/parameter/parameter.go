@@ -1,9 +0,0 @@
-package parameter
-
-type Parameter struct {
-	Id      string
-	Minimum float32
-	Maximum float32
-	Default float32
-	Current float32
-}
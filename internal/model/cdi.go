@@ -18,3 +18,33 @@ type CdiJson struct {
 		Name string `json:"Name"`
 	} `json:"Parts"`
 }
+
+// Get the display name and the group ID of the parameter
+func (c *CdiJson) GetParameterName(id string) (name, groupId string) {
+	for _, p := range c.Parameters {
+		if p.Id == id {
+			return p.Name, p.GroupId
+		}
+	}
+	return
+}
+
+// Get the display name of the parameter group
+func (c *CdiJson) GetParameterGroupName(id string) string {
+	for _, g := range c.ParameterGroups {
+		if g.Id == id {
+			return g.Name
+		}
+	}
+	return ""
+}
+
+// Get the display name of the part
+func (c *CdiJson) GetPartName(id string) string {
+	for _, p := range c.Parts {
+		if p.Id == id {
+			return p.Name
+		}
+	}
+	return ""
+}
@@ -0,0 +1,83 @@
+package model_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/aethiopicuschan/cubism-go/internal/model"
+	"github.com/stretchr/testify/assert"
+)
+
+const cdiSrc = `{
+	"Version": 3,
+	"Parameters": [
+		{"Id": "ParamAngleX", "GroupId": "ParamGroupFace", "Name": "Angle X"},
+		{"Id": "ParamBreath", "GroupId": "", "Name": "Breath"}
+	],
+	"ParameterGroups": [
+		{"Id": "ParamGroupFace", "GroupId": "", "Name": "Face"}
+	],
+	"Parts": [
+		{"Id": "PartArmA", "Name": "Arm A"}
+	]
+}`
+
+func TestGetParameterName(t *testing.T) {
+	var cdi model.CdiJson
+	if err := json.Unmarshal([]byte(cdiSrc), &cdi); err != nil {
+		t.Fatal(err)
+	}
+
+	testcases := []struct {
+		name          string
+		id            string
+		expectName    string
+		expectGroupId string
+	}{
+		{
+			name:          "grouped",
+			id:            "ParamAngleX",
+			expectName:    "Angle X",
+			expectGroupId: "ParamGroupFace",
+		},
+		{
+			name:          "ungrouped",
+			id:            "ParamBreath",
+			expectName:    "Breath",
+			expectGroupId: "",
+		},
+		{
+			name:          "unknown",
+			id:            "ParamUnknown",
+			expectName:    "",
+			expectGroupId: "",
+		},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.name, func(t *testing.T) {
+			t.Parallel()
+			name, groupId := cdi.GetParameterName(testcase.id)
+			assert.Equal(t, testcase.expectName, name)
+			assert.Equal(t, testcase.expectGroupId, groupId)
+		})
+	}
+}
+
+func TestGetParameterGroupName(t *testing.T) {
+	var cdi model.CdiJson
+	if err := json.Unmarshal([]byte(cdiSrc), &cdi); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "Face", cdi.GetParameterGroupName("ParamGroupFace"))
+	assert.Equal(t, "", cdi.GetParameterGroupName("ParamGroupUnknown"))
+}
+
+func TestGetPartName(t *testing.T) {
+	var cdi model.CdiJson
+	if err := json.Unmarshal([]byte(cdiSrc), &cdi); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "Arm A", cdi.GetPartName("PartArmA"))
+	assert.Equal(t, "", cdi.GetPartName("PartUnknown"))
+}
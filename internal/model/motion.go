@@ -1,32 +1,53 @@
 package model
 
-import "github.com/aethiopicuschan/cubism-go/internal/motion"
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/aethiopicuschan/cubism-go/motion"
+)
 
 type Meta struct {
 	Duration             float64 `json:"Duration"`
+	Fps                  float64 `json:"Fps"`
 	Loop                 bool    `json:"Loop"`
 	AreBeziersRestricted bool    `json:"AreBeziersRestricted"`
+	CurveCount           int     `json:"CurveCount"`
+	TotalSegmentCount    int     `json:"TotalSegmentCount"`
+	TotalPointCount      int     `json:"TotalPointCount"`
+	UserDataCount        int     `json:"UserDataCount"`
+	TotalUserDataSize    int     `json:"TotalUserDataSize"`
+}
+
+type MotionUserData struct {
+	Time  float64 `json:"Time"`
+	Value string  `json:"Value"`
+}
+
+type MotionCurve struct {
+	Target      string    `json:"Target"`
+	Id          string    `json:"Id"`
+	FadeInTime  *float64  `json:"FadeInTime,omitempty"`
+	FadeOutTime *float64  `json:"FadeOutTime,omitempty"`
+	Segments    []float64 `json:"Segments"`
 }
 
 // struct for *.motion3.json files
 type MotionJson struct {
-	Version  int  `json:"Version"`
-	Meta     Meta `json:"Meta"`
-	UserData []struct {
-		Time  float64 `json:"Time"`
-		Value string  `json:"Value"`
-	} `json:"UserData"`
-	Curves []struct {
-		Target      string    `json:"Target"`
-		Id          string    `json:"Id"`
-		FadeInTime  *float64  `json:"FadeInTime"`
-		FadeOutTime *float64  `json:"FadeOutTime"`
-		Segments    []float64 `json:"Segments"`
-	} `json:"Curves"`
+	Version  int              `json:"Version"`
+	Meta     Meta             `json:"Meta"`
+	Curves   []MotionCurve    `json:"Curves"`
+	UserData []MotionUserData `json:"UserData,omitempty"`
 }
 
 // Convert motion3.json to Motion
-func (m *MotionJson) ToMotion(fp string, fadein, fadeout float64, sound string) (mtn motion.Motion) {
+// It returns an error if a curve is truncated or has an unknown segment, or if the counts of Meta do not match the curves
+func (m *MotionJson) ToMotion(fp string, fadein, fadeout float64, sound string) (mtn motion.Motion, err error) {
+	if m.Meta.Duration < 0 || math.IsNaN(m.Meta.Duration) || math.IsInf(m.Meta.Duration, 0) {
+		err = fmt.Errorf("invalid duration %g", m.Meta.Duration)
+		return
+	}
 	mtn = motion.Motion{
 		File:        fp,
 		FadeInTime:  fadein,
@@ -34,11 +55,25 @@ func (m *MotionJson) ToMotion(fp string, fadein, fadeout float64, sound string)
 		Sound:       sound,
 		Meta: motion.Meta{
 			Duration:             m.Meta.Duration,
+			Fps:                  m.Meta.Fps,
 			Loop:                 m.Meta.Loop,
 			AreBeziersRestricted: m.Meta.AreBeziersRestricted,
 		},
 	}
-	for _, curve := range m.Curves {
+	for _, u := range m.UserData {
+		mtn.UserData = append(mtn.UserData, motion.UserData{
+			Time:  u.Time,
+			Value: u.Value,
+		})
+	}
+	sort.SliceStable(mtn.UserData, func(i, j int) bool {
+		return mtn.UserData[i].Time < mtn.UserData[j].Time
+	})
+	for ci, curve := range m.Curves {
+		if len(curve.Segments) < 2 {
+			err = fmt.Errorf("curve %d (%s) has no start point", ci, curve.Id)
+			return
+		}
 		var c motion.Curve
 		c.Target = curve.Target
 		c.Id = curve.Id
@@ -52,16 +87,21 @@ func (m *MotionJson) ToMotion(fp string, fadein, fadeout float64, sound string)
 		} else {
 			c.FadeOutTime = *curve.FadeOutTime
 		}
-		var lastPoint motion.Point
-		for i := 0; i < len(curve.Segments); {
-			if i == 0 {
-				lastPoint = motion.Point{
-					Time:  curve.Segments[i],
-					Value: curve.Segments[i+1],
-				}
-				i += 2
-			}
+		lastPoint := motion.Point{
+			Time:  curve.Segments[0],
+			Value: curve.Segments[1],
+		}
+		for i := 2; i < len(curve.Segments); {
 			segment := curve.Segments[i]
+			size := segmentSize(segment)
+			if size == 0 {
+				err = fmt.Errorf("curve %d (%s) has an unknown segment type %g at %d", ci, curve.Id, segment, i)
+				return
+			}
+			if i+size > len(curve.Segments) {
+				err = fmt.Errorf("curve %d (%s) is truncated at %d", ci, curve.Id, i)
+				return
+			}
 			switch segment {
 			case motion.Linear:
 				nextPoint := motion.Point{
@@ -110,38 +150,158 @@ func (m *MotionJson) ToMotion(fp string, fadein, fadeout float64, sound string)
 			case motion.Stepped:
 				t0 := curve.Segments[i+1]
 				v0 := curve.Segments[i+2]
+				nextPoint := motion.Point{
+					Time:  t0,
+					Value: v0,
+				}
 
 				c.Segments = append(c.Segments, motion.Segment{
 					Points: []motion.Point{
 						lastPoint,
+						nextPoint,
 					},
 					Type:  motion.Stepped,
 					Value: t0,
 				})
-				lastPoint = motion.Point{
-					Time:  t0,
-					Value: v0,
-				}
+				lastPoint = nextPoint
 				i += 3
 			case motion.InverseStepped:
 				t0 := curve.Segments[i+1]
 				v0 := curve.Segments[i+2]
-				tn := lastPoint.Time
-				lastPoint = motion.Point{
+				nextPoint := motion.Point{
 					Time:  t0,
 					Value: v0,
 				}
 				c.Segments = append(c.Segments, motion.Segment{
 					Points: []motion.Point{
+						nextPoint,
 						lastPoint,
 					},
 					Type:  motion.InverseStepped,
-					Value: tn,
+					Value: lastPoint.Time,
 				})
+				lastPoint = nextPoint
 				i += 3
 			}
 		}
+		if len(c.Segments) == 0 {
+			err = fmt.Errorf("curve %d (%s) has no segments", ci, curve.Id)
+			return
+		}
 		mtn.Curves = append(mtn.Curves, c)
 	}
+	err = m.validateMeta(mtn)
 	return
 }
+
+// Get the number of values making up a segment including its type, or 0 if the type is unknown
+func segmentSize(segment float64) int {
+	switch segment {
+	case motion.Linear, motion.Stepped, motion.InverseStepped:
+		return 3
+	case motion.Bezier:
+		return 7
+	}
+	return 0
+}
+
+// Check the counts of Meta against the motion
+// The counts that are not set are not checked
+func (m *MotionJson) validateMeta(mtn motion.Motion) error {
+	actual := NewMotionJson(mtn).Meta
+	counts := []struct {
+		name            string
+		declared, count int
+	}{
+		{"CurveCount", m.Meta.CurveCount, actual.CurveCount},
+		{"TotalSegmentCount", m.Meta.TotalSegmentCount, actual.TotalSegmentCount},
+		{"TotalPointCount", m.Meta.TotalPointCount, actual.TotalPointCount},
+		{"UserDataCount", m.Meta.UserDataCount, actual.UserDataCount},
+	}
+	for _, c := range counts {
+		if c.declared != 0 && c.declared != c.count {
+			return fmt.Errorf("%s is %d but the motion has %d", c.name, c.declared, c.count)
+		}
+	}
+	return nil
+}
+
+// Convert Motion to motion3.json, computing the counts of Meta
+// The fade times and the sound of the motion are not included, as they belong to model3.json
+func NewMotionJson(mtn motion.Motion) (m MotionJson) {
+	m = MotionJson{
+		Version: 3,
+		Meta: Meta{
+			Duration:             mtn.Meta.Duration,
+			Fps:                  mtn.Meta.Fps,
+			Loop:                 mtn.Meta.Loop,
+			AreBeziersRestricted: mtn.Meta.AreBeziersRestricted,
+			CurveCount:           len(mtn.Curves),
+			UserDataCount:        len(mtn.UserData),
+		},
+		Curves: []MotionCurve{},
+	}
+	for _, u := range mtn.UserData {
+		m.UserData = append(m.UserData, MotionUserData{
+			Time:  u.Time,
+			Value: u.Value,
+		})
+		m.Meta.TotalUserDataSize += len(u.Value)
+	}
+	for _, curve := range mtn.Curves {
+		c := MotionCurve{
+			Target:   curve.Target,
+			Id:       curve.Id,
+			Segments: []float64{},
+		}
+		if curve.FadeInTime >= 0 {
+			c.FadeInTime = &curve.FadeInTime
+		}
+		if curve.FadeOutTime >= 0 {
+			c.FadeOutTime = &curve.FadeOutTime
+		}
+		for i, seg := range curve.Segments {
+			if i == 0 {
+				start := segmentStart(seg)
+				c.Segments = append(c.Segments, start.Time, start.Value)
+				m.Meta.TotalPointCount++
+			}
+			switch seg.Type {
+			case motion.Linear:
+				c.Segments = append(c.Segments, motion.Linear, seg.Points[1].Time, seg.Points[1].Value)
+				m.Meta.TotalPointCount++
+			case motion.Bezier:
+				c.Segments = append(c.Segments, motion.Bezier)
+				for _, p := range seg.Points[1:4] {
+					c.Segments = append(c.Segments, p.Time, p.Value)
+				}
+				m.Meta.TotalPointCount += 3
+			case motion.Stepped:
+				end := motion.Point{Time: seg.Value, Value: seg.Points[0].Value}
+				if len(seg.Points) > 1 {
+					end = seg.Points[1]
+				}
+				c.Segments = append(c.Segments, motion.Stepped, end.Time, end.Value)
+				m.Meta.TotalPointCount++
+			case motion.InverseStepped:
+				c.Segments = append(c.Segments, motion.InverseStepped, seg.Points[0].Time, seg.Points[0].Value)
+				m.Meta.TotalPointCount++
+			}
+			m.Meta.TotalSegmentCount++
+		}
+		m.Curves = append(m.Curves, c)
+	}
+	return
+}
+
+// Get the first point of the segment
+func segmentStart(seg motion.Segment) motion.Point {
+	if seg.Type != motion.InverseStepped {
+		return seg.Points[0]
+	}
+	if len(seg.Points) > 1 {
+		return seg.Points[1]
+	}
+	// The value of the start is unknown, so the value of the end is held from there
+	return motion.Point{Time: seg.Value, Value: seg.Points[0].Value}
+}
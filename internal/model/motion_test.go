@@ -0,0 +1,135 @@
+package model_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aethiopicuschan/cubism-go/core/fake"
+	"github.com/aethiopicuschan/cubism-go/internal/model"
+	"github.com/aethiopicuschan/cubism-go/motion"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const motionSrc = `{
+	"Version": 3,
+	"Meta": {"Duration": 2, "Loop": true},
+	"Curves": [
+		{"Target": "Parameter", "Id": "ParamAngleX", "Segments": [0, 0, 0, 2, 10]}
+	],
+	"UserData": [
+		{"Time": 1.5, "Value": "second"},
+		{"Time": 0.5, "Value": "first"}
+	]
+}`
+
+func TestToMotionUserData(t *testing.T) {
+	var mj model.MotionJson
+	require.NoError(t, json.Unmarshal([]byte(motionSrc), &mj))
+	mtn, err := mj.ToMotion("Test.motion3.json", 0, 0, "")
+	require.NoError(t, err)
+	assert.Equal(t, []motion.UserData{
+		{Time: 0.5, Value: "first"},
+		{Time: 1.5, Value: "second"},
+	}, mtn.UserData)
+}
+
+func TestMotionJsonRoundTrip(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	_, err := fake.WriteFixture(dir)
+	require.NoError(t, err)
+
+	inverse := `{
+		"Version": 3,
+		"Meta": {"Duration": 3, "Fps": 60, "CurveCount": 1, "TotalSegmentCount": 3, "TotalPointCount": 4},
+		"Curves": [
+			{"Target": "Parameter", "Id": "ParamAngleX", "FadeInTime": 0.2, "Segments": [0, 1, 3, 1, 5, 2, 2, 0, 3, 3, 4]}
+		]
+	}`
+	tests := []struct {
+		name string
+		src  func() ([]byte, error)
+	}{
+		{name: "idle", src: func() ([]byte, error) { return os.ReadFile(filepath.Join(dir, "motions", "Idle.motion3.json")) }},
+		{name: "tap", src: func() ([]byte, error) { return os.ReadFile(filepath.Join(dir, "motions", "Tap.motion3.json")) }},
+		{name: "inverse stepped", src: func() ([]byte, error) { return []byte(inverse), nil }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			src, err := tt.src()
+			require.NoError(t, err)
+			var mj model.MotionJson
+			require.NoError(t, json.Unmarshal(src, &mj))
+			mtn, err := mj.ToMotion("Test.motion3.json", 0, 0, "")
+			require.NoError(t, err)
+
+			written := model.NewMotionJson(mtn)
+			// The counts match those written by Cubism Editor
+			assert.Equal(t, mj.Meta, written.Meta)
+			b, err := json.Marshal(written)
+			require.NoError(t, err)
+			var read model.MotionJson
+			require.NoError(t, json.Unmarshal(b, &read))
+			assert.Equal(t, mj, read)
+			readMtn, err := read.ToMotion("Test.motion3.json", 0, 0, "")
+			require.NoError(t, err)
+			assert.Equal(t, mtn, readMtn)
+		})
+	}
+}
+
+func TestToMotionErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		src  string
+	}{
+		{name: "no start point", src: `{"Curves": [{"Id": "ParamAngleX", "Segments": [0]}]}`},
+		{name: "no segments", src: `{"Curves": [{"Id": "ParamAngleX", "Segments": [0, 0]}]}`},
+		{name: "truncated linear", src: `{"Curves": [{"Id": "ParamAngleX", "Segments": [0, 0, 0, 1]}]}`},
+		{name: "truncated bezier", src: `{"Curves": [{"Id": "ParamAngleX", "Segments": [0, 0, 1, 0.3, 1, 0.6, 1, 1]}]}`},
+		{name: "unknown segment", src: `{"Curves": [{"Id": "ParamAngleX", "Segments": [0, 0, 4, 1, 1]}]}`},
+		{name: "fractional segment", src: `{"Curves": [{"Id": "ParamAngleX", "Segments": [0, 0, 0.5, 1, 1]}]}`},
+		{name: "negative duration", src: `{"Meta": {"Duration": -1}}`},
+		{name: "curve count", src: `{"Meta": {"CurveCount": 2}, "Curves": [{"Id": "ParamAngleX", "Segments": [0, 0, 0, 1, 1]}]}`},
+		{name: "segment count", src: `{"Meta": {"TotalSegmentCount": 2}, "Curves": [{"Id": "ParamAngleX", "Segments": [0, 0, 0, 1, 1]}]}`},
+		{name: "point count", src: `{"Meta": {"TotalPointCount": 3}, "Curves": [{"Id": "ParamAngleX", "Segments": [0, 0, 0, 1, 1]}]}`},
+		{name: "user data count", src: `{"Meta": {"UserDataCount": 2}, "UserData": [{"Time": 0, "Value": "a"}]}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var mj model.MotionJson
+			require.NoError(t, json.Unmarshal([]byte(tt.src), &mj))
+			_, err := mj.ToMotion("Test.motion3.json", 0, 0, "")
+			assert.Error(t, err)
+		})
+	}
+}
+
+func FuzzToMotion(f *testing.F) {
+	f.Add([]byte(motionSrc))
+	f.Add([]byte(`{"Curves": [{"Id": "ParamAngleX", "Segments": [0, 0, 1, 0.3, 1, 0.6, 1, 1, 1, 2, 3, 1.5, 0, 2, 2, 1]}]}`))
+	f.Add([]byte(`{"Meta": {"CurveCount": 1}, "Curves": [{"Id": "ParamAngleX", "Segments": [0, 0, 4]}]}`))
+	f.Fuzz(func(t *testing.T, src []byte) {
+		var mj model.MotionJson
+		if json.Unmarshal(src, &mj) != nil {
+			return
+		}
+		mtn, err := mj.ToMotion("Test.motion3.json", 0, 0, "")
+		if err != nil {
+			return
+		}
+		// A valid motion is written back into a valid motion3.json
+		written := model.NewMotionJson(mtn)
+		_, err = written.ToMotion("Test.motion3.json", 0, 0, "")
+		assert.NoError(t, err)
+	})
+}
@@ -0,0 +1,127 @@
+package motion_test
+
+import (
+	"testing"
+
+	"github.com/aethiopicuschan/cubism-go/core/fake"
+	internalmotion "github.com/aethiopicuschan/cubism-go/internal/motion"
+	"github.com/aethiopicuschan/cubism-go/motion"
+	"github.com/stretchr/testify/assert"
+)
+
+// A motion of a single Bezier segment for ParamAngleX
+func bezierMotion(points []motion.Point, restricted bool) motion.Motion {
+	return motion.Motion{
+		Meta: motion.Meta{
+			Duration:             points[3].Time,
+			AreBeziersRestricted: restricted,
+		},
+		Curves: []motion.Curve{
+			{
+				Target:      "Parameter",
+				Id:          "ParamAngleX",
+				FadeInTime:  -1,
+				FadeOutTime: -1,
+				Segments: []motion.Segment{
+					{Type: motion.Bezier, Points: points},
+				},
+			},
+		},
+	}
+}
+
+// Evaluate the Bezier curve at the time t by bisecting its parameter, as the time increases along the curves below
+func bisectBezier(points []motion.Point, t float64) float64 {
+	at := func(u float64) (p motion.Point) {
+		m := 1 - u
+		for i, b := range []float64{m * m * m, 3 * m * m * u, 3 * m * u * u, u * u * u} {
+			p.Time += b * points[i].Time
+			p.Value += b * points[i].Value
+		}
+		return
+	}
+	lo, hi := 0.0, 1.0
+	for i := 0; i < 100; i++ {
+		mid := (lo + hi) / 2
+		if at(mid).Time < t {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return at((lo + hi) / 2).Value
+}
+
+func TestBezier(t *testing.T) {
+	t.Parallel()
+
+	// The values are stored as float32, which is far more precise than the tolerance
+	const tolerance = 1e-4
+	tests := []struct {
+		name       string
+		points     []motion.Point
+		restricted bool
+		times      []float64
+		// The values of the curve, computed by bisectBezier if nil
+		want []float64
+	}{
+		{
+			name:   "ease",
+			points: []motion.Point{{Time: 0, Value: 0}, {Time: 0.9, Value: 0}, {Time: 0.1, Value: 10}, {Time: 1, Value: 10}},
+			times:  []float64{0.1, 0.25, 0.5, 0.75, 0.9},
+		},
+		{
+			name:   "ease in",
+			points: []motion.Point{{Time: 0, Value: 0}, {Time: 0.5, Value: 0}, {Time: 0.9, Value: 8}, {Time: 1, Value: 10}},
+			times:  []float64{0.1, 0.25, 0.5, 0.75, 0.9},
+		},
+		{
+			name:   "editor",
+			points: []motion.Point{{Time: 0, Value: 0}, {Time: 0.333, Value: 0}, {Time: 0.667, Value: 10}, {Time: 1, Value: 10}},
+			times:  []float64{0.1, 0.25, 0.5, 0.75, 0.9},
+		},
+		{
+			name:   "asymmetric",
+			points: []motion.Point{{Time: 0, Value: 0}, {Time: 0.05, Value: 10}, {Time: 0.6, Value: -5}, {Time: 1, Value: 5}},
+			times:  []float64{0.1, 0.25, 0.5, 0.75, 0.9},
+		},
+		{
+			// The time is a quadratic function of the parameter, see quadratic in math.go
+			name:   "quadratic time",
+			points: []motion.Point{{Time: 0, Value: 0}, {Time: 0.1, Value: 10}, {Time: 1.3 / 3, Value: -5}, {Time: 1, Value: 5}},
+			times:  []float64{0.1, 0.25, 0.5, 0.75, 0.9},
+		},
+		{
+			name:   "steep",
+			points: []motion.Point{{Time: 1, Value: -10}, {Time: 1, Value: 10}, {Time: 1.5, Value: 10}, {Time: 2, Value: 20}},
+			times:  []float64{1.1, 1.25, 1.5, 1.75, 1.9},
+		},
+		{
+			// The parameter is proportional to the time, so the values are exact
+			name:       "restricted",
+			points:     []motion.Point{{Time: 0, Value: 0}, {Time: 0.9, Value: 0}, {Time: 0.1, Value: 10}, {Time: 1, Value: 10}},
+			restricted: true,
+			times:      []float64{0.1, 0.25, 0.5, 0.75, 0.9},
+			want:       []float64{0.28, 1.5625, 5, 8.4375, 9.72},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			c, m := fake.LoadFixture(t)
+			modelPtr := m.ModelPtr
+			mm := internalmotion.NewMotionManager(c, modelPtr, func(id int) {})
+			id := mm.Start(bezierMotion(tt.points, tt.restricted))
+			for i, time := range tt.times {
+				want := bisectBezier(tt.points, time)
+				if tt.want != nil {
+					want = tt.want[i]
+				}
+				mm.Seek(id, time)
+				mm.Update(0)
+				assert.InDelta(t, want, c.GetParameterValue(modelPtr, "ParamAngleX"), tolerance, "at %g", time)
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package motion
+
+import "github.com/aethiopicuschan/cubism-go/motion"
+
+// Finds the segment of a curve active at a time
+// The end times are computed once, so that the segment is found by a binary search,
+// or in constant time while the playback stays in the same segment or moves to the next one
+type curveCursor struct {
+	// End time of each segment, in ascending order
+	ends []float64
+	// Index of the segment found last
+	index int
+}
+
+func newCurveCursor(curve motion.Curve) (c curveCursor) {
+	c.ends = make([]float64, len(curve.Segments))
+	for i, seg := range curve.Segments {
+		c.ends[i] = segmentEnd(seg)
+	}
+	return
+}
+
+// Get the index of the first segment ending after t, the last one if t is beyond the curve, or -1 if there is none
+func (c *curveCursor) find(t float64) int {
+	n := len(c.ends)
+	if n == 0 {
+		return -1
+	}
+	if c.contains(c.index, t) {
+		return c.index
+	}
+	if c.index+1 < n && c.contains(c.index+1, t) {
+		c.index++
+		return c.index
+	}
+	lo, hi := 0, n-1
+	for lo < hi {
+		mid := int(uint(lo+hi) >> 1)
+		if c.ends[mid] > t {
+			hi = mid
+		} else {
+			lo = mid + 1
+		}
+	}
+	c.index = lo
+	return lo
+}
+
+// Check whether the segment i is the one active at t
+func (c *curveCursor) contains(i int, t float64) bool {
+	if i > 0 && c.ends[i-1] > t {
+		return false
+	}
+	return c.ends[i] > t || i == len(c.ends)-1
+}
+
+func segmentEnd(segment motion.Segment) float64 {
+	switch segment.Type {
+	case motion.Linear:
+		return segment.Points[1].Time
+	case motion.Bezier:
+		return segment.Points[3].Time
+	case motion.Stepped:
+		return segment.Value
+	case motion.InverseStepped:
+		return segment.Points[0].Time
+	}
+	return 0
+}
@@ -0,0 +1,148 @@
+package motion_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/aethiopicuschan/cubism-go/core/fake"
+	internalmotion "github.com/aethiopicuschan/cubism-go/internal/motion"
+	"github.com/aethiopicuschan/cubism-go/motion"
+	"github.com/stretchr/testify/assert"
+)
+
+// A motion of n linear segments of 10ms for ParamAngleX, whose points take the values 0 to 6 in turn
+func longMotion(n int) motion.Motion {
+	curve := motion.Curve{
+		Target:      "Parameter",
+		Id:          "ParamAngleX",
+		FadeInTime:  -1,
+		FadeOutTime: -1,
+	}
+	for i := 0; i < n; i++ {
+		curve.Segments = append(curve.Segments, motion.Segment{
+			Type: motion.Linear,
+			Points: []motion.Point{
+				{Time: float64(i) / 100, Value: float64(i % 7)},
+				{Time: float64(i+1) / 100, Value: float64((i + 1) % 7)},
+			},
+		})
+	}
+	return motion.Motion{
+		Meta:   motion.Meta{Duration: float64(n) / 100},
+		Curves: []motion.Curve{curve},
+	}
+}
+
+func TestCurveEvaluation(t *testing.T) {
+	t.Parallel()
+	c, m := fake.LoadFixture(t)
+	modelPtr := m.ModelPtr
+
+	mm := internalmotion.NewMotionManager(c, modelPtr, func(id int) {})
+	id := mm.Start(longMotion(1000))
+	// Forward, backward and jumping around
+	for _, segment := range []int{0, 1, 2, 500, 499, 10, 999, 998, 3} {
+		mm.Seek(id, (float64(segment)+0.25)/100)
+		mm.Update(0)
+		from, to := float64(segment%7), float64((segment+1)%7)
+		assert.InDelta(t, from+(to-from)*0.25, c.GetParameterValue(modelPtr, "ParamAngleX"), 1e-4, "segment %d", segment)
+	}
+}
+
+func TestCurveEvaluatedOnce(t *testing.T) {
+	t.Parallel()
+	c, m := fake.LoadFixture(t)
+	modelPtr := m.ModelPtr
+
+	mtn := motion.Motion{
+		Meta: motion.Meta{Duration: 2},
+		Curves: []motion.Curve{
+			{
+				Target:      "Parameter",
+				Id:          "ParamAngleX",
+				FadeInTime:  -1,
+				FadeOutTime: -1,
+				Segments: []motion.Segment{
+					{Type: motion.Stepped, Points: []motion.Point{{Time: 0, Value: 2}}, Value: 1},
+					{Type: motion.Stepped, Points: []motion.Point{{Time: 1, Value: 8}}, Value: 2},
+				},
+			},
+		},
+	}
+	mm := internalmotion.NewMotionManager(c, modelPtr, func(id int) {})
+	id := mm.Start(mtn)
+	mm.SetWeight(id, 0.5)
+	// At the boundary, only the segment starting there is applied
+	mm.Seek(id, 1)
+	mm.Update(0)
+	assert.InDelta(t, 4, c.GetParameterValue(modelPtr, "ParamAngleX"), 1e-5)
+}
+
+func BenchmarkMotionManagerUpdate(b *testing.B) {
+	for _, n := range []int{10, 1000, 100000} {
+		b.Run(fmt.Sprintf("%d segments", n), func(b *testing.B) {
+			c, m := fake.LoadFixture(b)
+			modelPtr := m.ModelPtr
+			mtn := longMotion(n)
+			mm := internalmotion.NewMotionManager(c, modelPtr, func(id int) {})
+			id := mm.Start(mtn)
+			mm.SetLoop(id, true, false)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				mm.Update(1.0 / 60)
+			}
+		})
+	}
+}
+
+func BenchmarkMotionManagerSeek(b *testing.B) {
+	c, m := fake.LoadFixture(b)
+	modelPtr := m.ModelPtr
+	mtn := longMotion(100000)
+	mm := internalmotion.NewMotionManager(c, modelPtr, func(id int) {})
+	id := mm.Start(mtn)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		// Jump across the motion so that the cursor misses every time
+		mm.Seek(id, float64(i*7919%100000)/100)
+		mm.Update(0)
+	}
+}
+
+func TestCurveEnd(t *testing.T) {
+	t.Parallel()
+
+	start := motion.Point{Time: 0, Value: 0}
+	end := motion.Point{Time: 1, Value: 10}
+	tests := []struct {
+		name     string
+		curve    *motion.CurveBuilder
+		duration float64
+		time     float64
+	}{
+		{name: "linear", curve: motion.NewCurveBuilder("Parameter", "ParamAngleX", start).Linear(end), duration: 1, time: 1},
+		{name: "stepped", curve: motion.NewCurveBuilder("Parameter", "ParamAngleX", start).Stepped(end), duration: 1, time: 1},
+		{name: "stepped after the end", curve: motion.NewCurveBuilder("Parameter", "ParamAngleX", start).Stepped(end), duration: 2, time: 1.5},
+		{name: "inverse stepped", curve: motion.NewCurveBuilder("Parameter", "ParamAngleX", start).InverseStepped(end), duration: 1, time: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			c, m := fake.LoadFixture(t)
+			modelPtr := m.ModelPtr
+			mtn := motion.Motion{
+				Meta:   motion.Meta{Duration: tt.duration},
+				Curves: []motion.Curve{tt.curve.Build()},
+			}
+			mm := internalmotion.NewMotionManager(c, modelPtr, func(id int) {})
+			id := mm.Start(mtn)
+			mm.Seek(id, tt.time)
+			mm.Update(0)
+			// The value of the last point is held at and after the end of the curve
+			assert.InDelta(t, 10, c.GetParameterValue(modelPtr, "ParamAngleX"), 1e-5)
+		})
+	}
+}
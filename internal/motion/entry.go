@@ -1,16 +1,129 @@
 package motion
 
+import (
+	"math"
+
+	"github.com/aethiopicuschan/cubism-go/motion"
+)
+
 type Entry struct {
-	motion      Motion
+	motion      motion.Motion
 	id          int
 	currentTime float64
+	// Whether the sound has been started in the current iteration
+	started bool
+	// Whether the events at the current time are still to be fired, after starting, looping, resetting or seeking
+	inclusive bool
+	paused    bool
+	// Playback speed, negative values play the motion in reverse
+	speed float64
+	// Blend weight in [0, 1]
+	weight float64
+	loop   bool
+	// Whether to fade in again on each iteration of a loop
+	fadeInOnLoop bool
+	// Number of times the motion has looped
+	iteration int
+	// Cursor of each curve of the motion
+	cursors []curveCursor
 }
 
-func (e *Entry) Update(deltaTime float64) (finished bool) {
-	e.currentTime += deltaTime
-	if e.currentTime >= e.motion.Meta.Duration {
-		finished = true
+func newEntry(mtn motion.Motion, id int) Entry {
+	cursors := make([]curveCursor, len(mtn.Curves))
+	for i, curve := range mtn.Curves {
+		cursors[i] = newCurveCursor(curve)
+	}
+	return Entry{
+		motion:    mtn,
+		id:        id,
+		inclusive: true,
+		speed:     1,
+		weight:    1,
+		cursors:   cursors,
+	}
+}
+
+// Advance the time and call onEvent for the user data crossed in the order of playback
+// A looping entry wraps its time around the duration, and looped is the number of times it did
+// The events of the whole iterations skipped by a single update are fired only once
+// Otherwise the time is clamped to the ends of the motion when it finishes
+func (e *Entry) Update(deltaTime float64, onEvent func(motion.UserData)) (finished bool, looped int) {
+	if e.paused || e.speed == 0 {
 		return
 	}
+	duration := e.motion.Meta.Duration
+	prev := e.currentTime
+	e.currentTime += deltaTime * e.speed
+	if e.loop && duration > 0 {
+		var wraps float64
+		if e.speed > 0 && e.currentTime >= duration {
+			wraps = math.Floor(e.currentTime / duration)
+			e.fireEvents(prev, duration, onEvent)
+			e.inclusive = true
+			if wraps > 1 {
+				e.fireEvents(0, duration, onEvent)
+			}
+			e.currentTime = math.Mod(e.currentTime, duration)
+			prev = 0
+		} else if e.speed < 0 && e.currentTime <= 0 {
+			wraps = math.Floor(-e.currentTime/duration) + 1
+			e.fireEvents(prev, 0, onEvent)
+			e.inclusive = true
+			if wraps > 1 {
+				e.fireEvents(duration, 0, onEvent)
+			}
+			e.currentTime = duration + math.Mod(e.currentTime, duration)
+			prev = duration
+		}
+		if wraps > 0 {
+			// The sound and the events at the start are triggered again
+			e.started = false
+			looped = int(min(wraps, math.MaxInt32))
+			e.iteration += looped
+		}
+	}
+	e.fireEvents(prev, e.currentTime, onEvent)
+	e.inclusive = false
+	if e.speed > 0 && e.currentTime >= duration {
+		e.currentTime = duration
+		finished = true
+	}
+	if e.speed < 0 && e.currentTime <= 0 {
+		e.currentTime = 0
+		finished = true
+	}
 	return
 }
+
+// Fire the events from prev to the time t in the direction of playback
+func (e *Entry) fireEvents(prev, t float64, onEvent func(motion.UserData)) {
+	if onEvent == nil {
+		return
+	}
+	data := e.motion.UserData
+	if e.speed > 0 {
+		for _, u := range data {
+			if (u.Time > prev || e.inclusive && u.Time == prev) && u.Time <= t {
+				onEvent(u)
+			}
+		}
+		return
+	}
+	for i := len(data) - 1; i >= 0; i-- {
+		u := data[i]
+		if (u.Time < prev || e.inclusive && u.Time == prev) && u.Time >= t {
+			onEvent(u)
+		}
+	}
+}
+
+// Rewind to the beginning, which is the end when playing in reverse
+func (e *Entry) Reset() {
+	e.started = false
+	e.inclusive = true
+	if e.speed < 0 {
+		e.currentTime = e.motion.Meta.Duration
+		return
+	}
+	e.currentTime = 0
+}
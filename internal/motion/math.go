@@ -1,6 +1,10 @@
 package motion
 
-import "math"
+import (
+	"math"
+
+	"github.com/aethiopicuschan/cubism-go/motion"
+)
 
 func getEasingSine(value float64) float64 {
 	if value < 0.0 {
@@ -12,43 +16,28 @@ func getEasingSine(value float64) float64 {
 	return 0.5 - 0.5*math.Cos(value*math.Pi)
 }
 
-func lerpPoints(a Point, b Point, t float64) Point {
-	return Point{
+func lerpPoints(a motion.Point, b motion.Point, t float64) motion.Point {
+	return motion.Point{
 		Time:  a.Time + (b.Time-a.Time)*t,
 		Value: a.Value + (b.Value-a.Value)*t,
 	}
 }
 
-func segmentIntersects(segment Segment, t float64) bool {
-	if segment.Type == Linear {
-		return segment.Points[0].Time <= t && t <= segment.Points[1].Time
-	}
-	if segment.Type == Bezier {
-		return segment.Points[0].Time <= t && t <= segment.Points[3].Time
-	}
-	if segment.Type == Stepped {
-		return segment.Points[0].Time <= t && t <= segment.Value
-	}
-	if segment.Type == InverseStepped {
-		return segment.Value <= t && t <= segment.Points[0].Time
-	}
-	return false
-}
-
-func segmentInterpolate(segment Segment, t float64) float64 {
-	if segment.Type == Linear {
+// Interpolate the value of the segment at the time t
+// If restricted is false, the time of a Bezier segment is not proportional to its parameter, which is solved for
+func segmentInterpolate(segment motion.Segment, t float64, restricted bool) float64 {
+	if segment.Type == motion.Linear {
 		p0, p1 := segment.Points[0], segment.Points[1]
-		k := (t - p0.Time) / (p1.Time - p0.Time)
-		if k < 0.0 {
-			k = 0.0
-		}
+		k := max(0, min((t-p0.Time)/(p1.Time-p0.Time), 1))
 		return p0.Value + (p1.Value-p0.Value)*k
 	}
-	if segment.Type == Bezier {
+	if segment.Type == motion.Bezier {
 		p0, p1, p2, p3 := segment.Points[0], segment.Points[1], segment.Points[2], segment.Points[3]
-		k := (t - p0.Time) / (p3.Time - p0.Time)
-		if k < 0.0 {
-			k = 0.0
+		var k float64
+		if restricted {
+			k = max(0, min((t-p0.Time)/(p3.Time-p0.Time), 1))
+		} else {
+			k = solveBezierTime(p0.Time, p1.Time, p2.Time, p3.Time, t)
 		}
 
 		p01 := lerpPoints(p0, p1, k)
@@ -58,26 +47,119 @@ func segmentInterpolate(segment Segment, t float64) float64 {
 		p123 := lerpPoints(p12, p23, k)
 		return lerpPoints(p012, p123, k).Value
 	}
-	if segment.Type == Stepped {
+	if segment.Type == motion.Stepped {
+		// At the end of the curve, the value jumps to the end point
+		if len(segment.Points) > 1 && t >= segment.Value {
+			return segment.Points[1].Value
+		}
 		return segment.Points[0].Value
 	}
-	if segment.Type == InverseStepped {
+	if segment.Type == motion.InverseStepped {
 		return segment.Points[0].Value
 	}
 	return 0
 }
 
-func getFade(motion Motion, weight float64, t float64) (fadeIn, fadeOut, fadeWeight float64) {
+// Get the parameter in [0, 1] at which the Bezier curve with the control times x0 to x3 reaches the time t
+// The cubic equation is solved by the Cardano's method and the root is refined by the Newton's method
+func solveBezierTime(x0, x1, x2, x3, t float64) float64 {
+	a := x3 - 3*x2 + 3*x1 - x0
+	b := 3*x2 - 6*x1 + 3*x0
+	c := 3*x1 - 3*x0
+	d := x0 - t
+	k := cardano(a, b, c, d)
+	for i := 0; i < 4; i++ {
+		f := ((a*k+b)*k+c)*k + d
+		df := (3*a*k+2*b)*k + c
+		if math.Abs(f) < 1e-12 || math.Abs(df) < 1e-12 {
+			break
+		}
+		k = max(0, min(k-f/df, 1))
+	}
+	return k
+}
+
+// Get the root of a*k^3 + b*k^2 + c*k + d = 0 in [0, 1], clamped to the range
+// When there are several real roots, the first one within the threshold of the middle is chosen, in the order of the official SDK
+func cardano(a, b, c, d float64) float64 {
+	const epsilon = 1e-5
+	clamp := func(k float64) float64 {
+		return max(0, min(k, 1))
+	}
+	if math.Abs(a) < epsilon {
+		return clamp(quadratic(b, c, d))
+	}
+	ba := b / a
+	ca := c / a
+	da := d / a
+	p := (3*ca - ba*ba) / 3
+	p3 := p / 3
+	q := (2*ba*ba*ba - 9*ba*ca + 27*da) / 27
+	q2 := q / 2
+	discriminant := q2*q2 + p3*p3*p3
+
+	const center = 0.5
+	const threshold = center + 0.01
+	if discriminant < 0 {
+		// Three distinct real roots
+		mp3 := -p3
+		r := math.Sqrt(mp3 * mp3 * mp3)
+		phi := math.Acos(max(-1, min(-q/(2*r), 1)))
+		t1 := 2 * math.Cbrt(r)
+		for _, offset := range []float64{0, 2 * math.Pi} {
+			root := t1*math.Cos((phi+offset)/3) - ba/3
+			if math.Abs(root-center) < threshold {
+				return clamp(root)
+			}
+		}
+		return clamp(t1*math.Cos((phi+4*math.Pi)/3) - ba/3)
+	}
+	if discriminant == 0 {
+		// A double root
+		u := -math.Cbrt(q2)
+		root := 2*u - ba/3
+		if math.Abs(root-center) < threshold {
+			return clamp(root)
+		}
+		return clamp(-u - ba/3)
+	}
+	// A single real root
+	sd := math.Sqrt(discriminant)
+	return clamp(math.Cbrt(sd-q2) - math.Cbrt(sd+q2) - ba/3)
+}
+
+// Get the root of a*k^2 + b*k + c = 0 closest to the middle of [0, 1]
+// The official SDK always takes -(b+sqrt(b^2-4ac))/(2a), but b is positive for the times of a Bezier segment,
+// so that root is negative when a is positive, which holds the segment at its start,
+// and it is the later of the two crossings when a is negative
+func quadratic(a, b, c float64) float64 {
+	const epsilon = 1e-5
+	if math.Abs(a) < epsilon {
+		if math.Abs(b) < epsilon {
+			return -c
+		}
+		return -c / b
+	}
+	sd := math.Sqrt(max(0, b*b-4*a*c))
+	root1 := (-b + sd) / (2 * a)
+	root2 := (-b - sd) / (2 * a)
+	if math.Abs(root1-0.5) < math.Abs(root2-0.5) {
+		return root1
+	}
+	return root2
+}
+
+func getFade(mtn motion.Motion, weight float64, t float64, skipFadeIn, skipFadeOut bool) (fadeIn, fadeOut, fadeWeight float64) {
 	fadeWeight = weight
-	if motion.FadeInTime == 0.0 {
+	if mtn.FadeInTime == 0.0 || skipFadeIn {
 		fadeIn = 1.0
 	} else {
-		fadeIn = getEasingSine(t / motion.FadeInTime)
+		fadeIn = getEasingSine(t / mtn.FadeInTime)
 	}
-	if motion.FadeOutTime == 0.0 || motion.Meta.Duration < 0.0 {
+	if mtn.FadeOutTime == 0.0 || mtn.Meta.Duration < 0.0 || skipFadeOut {
 		fadeOut = 1.0
 	} else {
-		fadeOut = getEasingSine((motion.Meta.Duration - t) / motion.FadeOutTime)
+		fadeOut = getEasingSine((mtn.Meta.Duration - t) / mtn.FadeOutTime)
 	}
 	fadeWeight = fadeWeight * fadeIn * fadeOut
 	return
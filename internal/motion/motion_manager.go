@@ -1,47 +1,173 @@
 package motion
 
 import (
-	"github.com/aethiopicuschan/cubism-go/internal/core"
+	"github.com/aethiopicuschan/cubism-go/core"
+	"github.com/aethiopicuschan/cubism-go/motion"
+)
+
+// How the motions of a manager are combined with the current parameter values
+type Blend int
+
+const (
+	// Interpolate from the current values to the values of the motion
+	BlendOverride Blend = iota
+	// Add the difference between the values of the motion and the default values
+	BlendAdditive
 )
 
 type MotionManager struct {
-	core            core.Core
-	modelPtr        uintptr
-	queue           []Entry
-	lastId          int
-	onFinished      func(int)
-	savedParameters map[string]float32
+	core       core.Core
+	modelPtr   uintptr
+	queue      []Entry
+	lastId     int
+	onFinished func(int)
+	onEvent    func(int, motion.UserData)
+	onLooped   func(int)
+	blend      Blend
+	// Weight of the whole manager in [0, 1]
+	weight float64
+	// Default values of the parameters, for the additive blend
+	defaults map[string]float32
 }
 
 func NewMotionManager(core core.Core, modelPtr uintptr, onFinished func(int)) *MotionManager {
 	return &MotionManager{
-		core:            core,
-		modelPtr:        modelPtr,
-		queue:           []Entry{},
-		lastId:          0,
-		onFinished:      onFinished,
-		savedParameters: make(map[string]float32),
+		core:       core,
+		modelPtr:   modelPtr,
+		queue:      []Entry{},
+		lastId:     0,
+		onFinished: onFinished,
+		blend:      BlendOverride,
+		weight:     1,
 	}
 }
 
-func (mm *MotionManager) Start(motion Motion) int {
+// Set how the motions are combined with the current parameter values
+func (mm *MotionManager) SetBlend(blend Blend) {
+	mm.blend = blend
+}
+
+// Get how the motions are combined with the current parameter values
+func (mm *MotionManager) GetBlend() Blend {
+	return mm.blend
+}
+
+// Set the weight of the whole manager, clamped to [0, 1]
+// It is multiplied by the weights of the motions
+func (mm *MotionManager) SetManagerWeight(weight float64) {
+	mm.weight = max(0, min(weight, 1))
+}
+
+// Get the weight of the whole manager
+func (mm *MotionManager) GetManagerWeight() float64 {
+	return mm.weight
+}
+
+// Check whether a motion is applied on the next Update
+func (mm *MotionManager) IsActive() bool {
+	return len(mm.queue) > 0
+}
+
+func (mm *MotionManager) Start(mtn motion.Motion) int {
 	mm.lastId++
-	mm.queue = append(mm.queue, Entry{
-		motion:      motion,
-		id:          mm.lastId,
-		currentTime: 0,
-	})
-	return mm.lastId
+	return mm.StartWithId(mtn, mm.lastId)
 }
 
-func (mm *MotionManager) Close(id int) {
-	index := -1
+// Start the motion with an id given by the caller
+// It is used to share the ids between several managers
+func (mm *MotionManager) StartWithId(mtn motion.Motion, id int) int {
+	mm.queue = append(mm.queue, newEntry(mtn, id))
+	return id
+}
+
+// Set the function called with the id of the motion when its playback crosses the time of user data
+func (mm *MotionManager) SetEventHandler(f func(int, motion.UserData)) {
+	mm.onEvent = f
+}
+
+// Set the function called with the id of a looping motion when it starts over
+// It is called once per update, even if the motion wrapped several times
+func (mm *MotionManager) SetLoopHandler(f func(int)) {
+	mm.onLooped = f
+}
+
+// Make the motion loop, wrapping its time around the duration
+// If fadeInOnLoop is false, the motion only fades in on the first iteration
+// It returns false if the motion is not playing
+func (mm *MotionManager) SetLoop(id int, loop bool, fadeInOnLoop bool) bool {
+	e := mm.find(id)
+	if e == nil {
+		return false
+	}
+	e.loop = loop
+	e.fadeInOnLoop = fadeInOnLoop
+	return true
+}
+
+// State of a motion in the queue
+type EntryState struct {
+	Id           int
+	Time         float64
+	Speed        float64
+	Weight       float64
+	Paused       bool
+	Loop         bool
+	FadeInOnLoop bool
+	Iteration    int
+}
+
+// Get the states of the motions in the order they were started
+func (mm *MotionManager) GetStates() (states []EntryState) {
+	for _, e := range mm.queue {
+		states = append(states, EntryState{
+			Id:           e.id,
+			Time:         e.currentTime,
+			Speed:        e.speed,
+			Weight:       e.weight,
+			Paused:       e.paused,
+			Loop:         e.loop,
+			FadeInOnLoop: e.fadeInOnLoop,
+			Iteration:    e.iteration,
+		})
+	}
+	return
+}
+
+// Start the motion from the state
+// Its sound is not played, as it cannot be started in the middle
+func (mm *MotionManager) StartWithState(mtn motion.Motion, s EntryState) {
+	e := newEntry(mtn, s.Id)
+	e.currentTime = max(0, min(s.Time, mtn.Meta.Duration))
+	e.started = true
+	e.speed = s.Speed
+	e.weight = max(0, min(s.Weight, 1))
+	e.paused = s.Paused
+	e.loop = s.Loop
+	e.fadeInOnLoop = s.FadeInOnLoop
+	e.iteration = s.Iteration
+	mm.queue = append(mm.queue, e)
+}
+
+func (mm *MotionManager) indexOf(id int) int {
 	for i, entry := range mm.queue {
 		if entry.id == id {
-			index = i
-			break
+			return i
 		}
 	}
+	return -1
+}
+
+// Get the entry with the id, or nil if it is not playing
+func (mm *MotionManager) find(id int) *Entry {
+	index := mm.indexOf(id)
+	if index == -1 {
+		return nil
+	}
+	return &mm.queue[index]
+}
+
+func (mm *MotionManager) Close(id int) {
+	index := mm.indexOf(id)
 	if index == -1 {
 		return
 	}
@@ -52,82 +178,180 @@ func (mm *MotionManager) Close(id int) {
 }
 
 func (mm *MotionManager) Reset(id int) {
-	index := -1
-	for i, entry := range mm.queue {
-		if entry.id == id {
-			index = i
-			break
-		}
+	if e := mm.find(id); e != nil {
+		e.Reset()
 	}
-	if index == -1 {
-		return
+}
+
+// Pause the motion and stop its sound
+// It returns false if the motion is not playing
+func (mm *MotionManager) Pause(id int) bool {
+	e := mm.find(id)
+	if e == nil {
+		return false
 	}
-	mm.queue[index].currentTime = 0
+	if !e.paused && e.motion.Sound != "" {
+		e.motion.LoadedSound.Close()
+	}
+	e.paused = true
+	return true
 }
 
-func (mm *MotionManager) saveParameters() {
-	parameters := mm.core.GetParameters(mm.modelPtr)
-	savedParameters := make(map[string]float32)
-	for _, parameter := range parameters {
-		savedParameters[parameter.Id] = parameter.Current
+// Resume the paused motion
+// Its sound stays stopped, as it cannot be started in the middle
+// It returns false if the motion is not playing
+func (mm *MotionManager) Resume(id int) bool {
+	e := mm.find(id)
+	if e == nil {
+		return false
 	}
-	mm.savedParameters = savedParameters
+	e.paused = false
+	return true
 }
 
-func (mm *MotionManager) loadParameters() {
-	if mm.savedParameters == nil {
+// Get the ids of the motions in the queue
+func (mm *MotionManager) GetIds() (ids []int) {
+	for _, entry := range mm.queue {
+		ids = append(ids, entry.id)
+	}
+	return
+}
+
+// Check whether the motion is playing
+func (mm *MotionManager) IsPlaying(id int) bool {
+	return mm.indexOf(id) != -1
+}
+
+// Check whether the motion is paused
+func (mm *MotionManager) IsPaused(id int) bool {
+	e := mm.find(id)
+	return e != nil && e.paused
+}
+
+// Move the motion to the time in seconds, clamped to its duration
+// The parameters are applied on the next Update, even if the motion is paused
+// It returns false if the motion is not playing
+func (mm *MotionManager) Seek(id int, t float64) bool {
+	e := mm.find(id)
+	if e == nil {
+		return false
+	}
+	e.currentTime = max(0, min(t, e.motion.Meta.Duration))
+	e.inclusive = true
+	return true
+}
+
+// Get the current time of the motion in seconds
+func (mm *MotionManager) GetTime(id int) (t float64, ok bool) {
+	e := mm.find(id)
+	if e == nil {
 		return
 	}
-	for id, value := range mm.savedParameters {
-		mm.core.SetParameterValue(mm.modelPtr, id, value)
+	return e.currentTime, true
+}
+
+// Set the playback speed of the motion
+// 1 is the normal speed, and negative values play the motion in reverse
+// A motion reversed at its beginning, such as one just started, is moved to its end to play from there
+// It returns false if the motion is not playing
+func (mm *MotionManager) SetSpeed(id int, speed float64) bool {
+	e := mm.find(id)
+	if e == nil {
+		return false
+	}
+	e.speed = speed
+	if speed < 0 && e.currentTime == 0 {
+		e.currentTime = e.motion.Meta.Duration
+		e.inclusive = true
 	}
+	return true
+}
+
+// Set the blend weight of the motion, clamped to [0, 1]
+// It returns false if the motion is not playing
+func (mm *MotionManager) SetWeight(id int, weight float64) bool {
+	e := mm.find(id)
+	if e == nil {
+		return false
+	}
+	e.weight = max(0, min(weight, 1))
+	return true
+}
+
+func (mm *MotionManager) defaultValue(id string) float32 {
+	if mm.defaults == nil {
+		mm.defaults = make(map[string]float32)
+		for _, p := range mm.core.GetParameters(mm.modelPtr) {
+			mm.defaults[p.Id] = p.Default
+		}
+	}
+	return mm.defaults[id]
 }
 
 func (mm *MotionManager) Update(deltaTime float64) {
 	if len(mm.queue) == 0 {
 		return
 	}
-	finished := mm.queue[len(mm.queue)-1].Update(deltaTime)
-	if finished {
-		mm.onFinished(mm.queue[len(mm.queue)-1].id)
+	top := &mm.queue[len(mm.queue)-1]
+	var onEvent func(motion.UserData)
+	if mm.onEvent != nil && len(top.motion.UserData) > 0 {
+		id := top.id
+		onEvent = func(u motion.UserData) {
+			mm.onEvent(id, u)
+		}
 	}
-	if len(mm.queue) == 0 {
-		return
+	finished, looped := top.Update(deltaTime, onEvent)
+	id := top.id
+	if mm.onLooped != nil && looped > 0 {
+		mm.onLooped(id)
+	}
+	// The last frame is applied before the motion is finished
+	mm.apply(top)
+	if finished {
+		mm.onFinished(id)
 	}
-	mm.loadParameters()
+}
 
-	entry := mm.queue[len(mm.queue)-1]
-	if entry.currentTime == deltaTime {
+// Apply the motion of the entry at its current time
+func (mm *MotionManager) apply(entry *Entry) {
+	if !entry.started && !entry.paused {
+		entry.started = true
 		if entry.motion.Sound != "" {
+			// Rewind the sound when it is retriggered by a loop
+			entry.motion.LoadedSound.Close()
 			entry.motion.LoadedSound.Play()
 		}
 	}
-	fadeIn, fadeOut, fadeWeight := getFade(entry.motion, 1.0, entry.currentTime)
-	for _, curve := range entry.motion.Curves {
-		for _, seg := range curve.Segments {
-			if !segmentIntersects(seg, entry.currentTime) {
-				continue
-			}
-			value := segmentInterpolate(seg, entry.currentTime)
+	weight := entry.weight * mm.weight
+	// A looping motion only fades in on the first iteration unless requested, and never fades out
+	skipFadeIn := entry.iteration > 0 && !entry.fadeInOnLoop
+	skipFadeOut := entry.loop
+	fadeIn, fadeOut, fadeWeight := getFade(entry.motion, weight, entry.currentTime, skipFadeIn, skipFadeOut)
+	for i, curve := range entry.motion.Curves {
+		// Each curve is evaluated once, by the segment active at the current time
+		if seg := entry.cursors[i].find(entry.currentTime); seg != -1 {
+			value := segmentInterpolate(curve.Segments[seg], entry.currentTime, entry.motion.Meta.AreBeziersRestricted)
 			if curve.Target == "Model" {
 				// TODO implement
 			}
 			if curve.Target == "PartOpacity" {
-				mm.core.SetPartOpacity(mm.modelPtr, curve.Id, float32(value))
+				sourceValue := core.GetPartOpacity(mm.core, mm.modelPtr, curve.Id)
+				// The part opacities are always overridden, as adding them is meaningless
+				mm.core.SetPartOpacity(mm.modelPtr, curve.Id, sourceValue+(float32(value)-sourceValue)*float32(weight))
 			}
 			if curve.Target == "Parameter" {
-				var v float32
+				var paramWeight float64
 				sourceValue := mm.core.GetParameterValue(mm.modelPtr, curve.Id)
 				if curve.FadeInTime < 0.0 && curve.FadeOutTime < 0.0 {
 					// If the fade is not set for the parameter, apply the motion fade
-					v = sourceValue + (float32(value)-sourceValue)*float32(fadeWeight)
+					paramWeight = fadeWeight
 				} else {
 					// If a fade is set for the parameter, apply that fade
 					var fin, fout float64
 					if curve.FadeInTime < 0 {
 						fin = fadeIn
 					} else {
-						if curve.FadeInTime == 0.0 {
+						if curve.FadeInTime == 0.0 || skipFadeIn {
 							fin = 1.0
 						} else {
 							fin = getEasingSine(entry.currentTime / curve.FadeInTime)
@@ -136,18 +360,22 @@ func (mm *MotionManager) Update(deltaTime float64) {
 					if curve.FadeOutTime < 0 {
 						fout = fadeOut
 					} else {
-						if curve.FadeOutTime == 0.0 {
+						if curve.FadeOutTime == 0.0 || skipFadeOut {
 							fout = 1.0
 						} else {
 							fout = getEasingSine((entry.motion.Meta.Duration - entry.currentTime) / curve.FadeOutTime)
 						}
 					}
-					paramWeight := 1.0 * fin * fout
+					paramWeight = weight * fin * fout
+				}
+				var v float32
+				if mm.blend == BlendAdditive {
+					v = sourceValue + (float32(value)-mm.defaultValue(curve.Id))*float32(paramWeight)
+				} else {
 					v = sourceValue + (float32(value)-sourceValue)*float32(paramWeight)
 				}
 				mm.core.SetParameterValue(mm.modelPtr, curve.Id, v)
 			}
 		}
 	}
-	mm.saveParameters()
 }
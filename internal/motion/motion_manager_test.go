@@ -0,0 +1,362 @@
+package motion_test
+
+import (
+	"testing"
+
+	"github.com/aethiopicuschan/cubism-go/core/fake"
+	internalmotion "github.com/aethiopicuschan/cubism-go/internal/motion"
+	"github.com/aethiopicuschan/cubism-go/motion"
+	"github.com/stretchr/testify/assert"
+)
+
+// A motion moving ParamAngleX linearly from 0 to 10 in one second
+func linearMotion() motion.Motion {
+	return motion.Motion{
+		Meta: motion.Meta{
+			Duration: 1,
+		},
+		Curves: []motion.Curve{
+			{
+				Target:      "Parameter",
+				Id:          "ParamAngleX",
+				FadeInTime:  -1,
+				FadeOutTime: -1,
+				Segments: []motion.Segment{
+					{
+						Type: motion.Linear,
+						Points: []motion.Point{
+							{Time: 0, Value: 0},
+							{Time: 1, Value: 10},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestMotionManager(t *testing.T) {
+	t.Parallel()
+	c, m := fake.LoadFixture(t)
+	modelPtr := m.ModelPtr
+
+	finished := []int{}
+	mm := internalmotion.NewMotionManager(c, modelPtr, func(id int) {
+		finished = append(finished, id)
+	})
+	id := mm.Start(linearMotion())
+
+	mm.Update(0.5)
+	assert.InDelta(t, 5, c.GetParameterValue(modelPtr, "ParamAngleX"), 1e-5)
+	assert.Empty(t, finished)
+
+	mm.Update(0.6)
+	assert.Equal(t, []int{id}, finished)
+}
+
+func TestMotionManagerClose(t *testing.T) {
+	t.Parallel()
+	c, m := fake.LoadFixture(t)
+	modelPtr := m.ModelPtr
+
+	mm := internalmotion.NewMotionManager(c, modelPtr, func(id int) {})
+	id := mm.Start(linearMotion())
+	mm.Update(0.5)
+	mm.Close(id)
+	c.SetParameterValue(modelPtr, "ParamAngleX", 0)
+	mm.Update(0.1)
+	assert.Equal(t, float32(0), c.GetParameterValue(modelPtr, "ParamAngleX"))
+}
+
+func TestMotionManagerControls(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		control func(mm *internalmotion.MotionManager, id int)
+		delta   float64
+		want    float32
+	}{
+		{
+			name:    "pause",
+			control: func(mm *internalmotion.MotionManager, id int) { mm.Pause(id) },
+			delta:   0.5,
+			want:    2,
+		},
+		{
+			name: "resume",
+			control: func(mm *internalmotion.MotionManager, id int) {
+				mm.Pause(id)
+				mm.Resume(id)
+			},
+			delta: 0.5,
+			want:  7,
+		},
+		{
+			name:    "seek",
+			control: func(mm *internalmotion.MotionManager, id int) { mm.Seek(id, 0.8) },
+			delta:   0,
+			want:    8,
+		},
+		{
+			name:    "seek beyond the duration",
+			control: func(mm *internalmotion.MotionManager, id int) { mm.Seek(id, -1) },
+			delta:   0,
+			want:    0,
+		},
+		{
+			name:    "speed",
+			control: func(mm *internalmotion.MotionManager, id int) { mm.SetSpeed(id, 2) },
+			delta:   0.3,
+			want:    8,
+		},
+		{
+			name:    "reverse",
+			control: func(mm *internalmotion.MotionManager, id int) { mm.SetSpeed(id, -1) },
+			delta:   0.1,
+			want:    1,
+		},
+		{
+			// Halfway between the value of the previous frame and the motion
+			name:    "weight",
+			control: func(mm *internalmotion.MotionManager, id int) { mm.SetWeight(id, 0.5) },
+			delta:   0.2,
+			want:    3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			c, m := fake.LoadFixture(t)
+			modelPtr := m.ModelPtr
+			mm := internalmotion.NewMotionManager(c, modelPtr, func(id int) {})
+			id := mm.Start(linearMotion())
+			mm.Update(0.2)
+			tt.control(mm, id)
+			mm.Update(tt.delta)
+			assert.InDelta(t, tt.want, c.GetParameterValue(modelPtr, "ParamAngleX"), 1e-5)
+		})
+	}
+}
+
+type countingSound struct {
+	played int
+	closed int
+}
+
+func (s *countingSound) Play() error {
+	s.played++
+	return nil
+}
+
+func (s *countingSound) Close() {
+	s.closed++
+}
+
+func TestMotionManagerPauseSound(t *testing.T) {
+	t.Parallel()
+	c, m := fake.LoadFixture(t)
+	modelPtr := m.ModelPtr
+
+	snd := &countingSound{}
+	mtn := linearMotion()
+	mtn.Sound = "sounds/Tap.wav"
+	mtn.LoadedSound = snd
+	mm := internalmotion.NewMotionManager(c, modelPtr, func(id int) {})
+	id := mm.Start(mtn)
+	mm.Update(0.1)
+	assert.Equal(t, 1, snd.played)
+
+	closed := snd.closed
+	assert.True(t, mm.Pause(id))
+	assert.Equal(t, closed+1, snd.closed)
+	// The sound stays stopped after resuming
+	assert.True(t, mm.Resume(id))
+	mm.Update(0.1)
+	assert.Equal(t, 1, snd.played)
+	assert.Equal(t, closed+1, snd.closed)
+}
+
+func TestMotionManagerReverseFinishes(t *testing.T) {
+	t.Parallel()
+	c, m := fake.LoadFixture(t)
+	modelPtr := m.ModelPtr
+
+	finished := []int{}
+	mm := internalmotion.NewMotionManager(c, modelPtr, func(id int) {
+		finished = append(finished, id)
+	})
+	id := mm.Start(linearMotion())
+	// A motion reversed before it is updated plays from its end
+	assert.True(t, mm.SetSpeed(id, -1))
+	mm.Update(0.1)
+	assert.Empty(t, finished)
+	assert.InDelta(t, 9, c.GetParameterValue(modelPtr, "ParamAngleX"), 1e-5)
+	mm.Update(1)
+	assert.Equal(t, []int{id}, finished)
+	assert.False(t, mm.SetSpeed(id+1, 1))
+}
+
+func TestMotionManagerLoop(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		loop         bool
+		fadeInOnLoop bool
+		speed        float64
+		duration     float64
+		deltas       []float64
+		wantValue    float32
+		wantLooped   int
+		wantIter     int
+		wantFinished bool
+	}{
+		{name: "last frame", deltas: []float64{1.2}, wantValue: 10, wantFinished: true},
+		{name: "wrap", loop: true, deltas: []float64{0.5, 0.75}, wantValue: 2.5, wantLooped: 1, wantIter: 1},
+		{name: "several wraps", loop: true, deltas: []float64{2.5}, wantValue: 5, wantLooped: 1, wantIter: 2},
+		{name: "reverse", loop: true, speed: -1, deltas: []float64{0.5, 0.75}, wantValue: 7.5, wantLooped: 1, wantIter: 1},
+		{name: "reverse several wraps", loop: true, speed: -1, deltas: []float64{2.25}, wantValue: 7.5, wantLooped: 1, wantIter: 2},
+		{name: "fade in on loop", loop: true, fadeInOnLoop: true, deltas: []float64{0.5, 0.75}, wantValue: 2.5 * 0.5, wantLooped: 1, wantIter: 1},
+		// The wraps are computed at once instead of one by one
+		{name: "tiny duration", loop: true, duration: 1.0 / 1024, deltas: []float64{1000}, wantValue: 0, wantLooped: 1, wantIter: 1024000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			c, m := fake.LoadFixture(t)
+			modelPtr := m.ModelPtr
+			finished := false
+			mm := internalmotion.NewMotionManager(c, modelPtr, func(id int) {
+				finished = true
+			})
+			looped := 0
+			mm.SetLoopHandler(func(id int) {
+				looped++
+			})
+			mtn := linearMotion()
+			mtn.FadeInTime = 0.5
+			if tt.duration != 0 {
+				mtn.Meta.Duration = tt.duration
+			}
+			id := mm.Start(mtn)
+			mm.SetLoop(id, tt.loop, tt.fadeInOnLoop)
+			if tt.speed != 0 {
+				mm.SetSpeed(id, tt.speed)
+			}
+			for _, d := range tt.deltas {
+				// Start from the default value so that the weight of the last frame is visible
+				c.SetParameterValue(modelPtr, "ParamAngleX", 0)
+				mm.Update(d)
+			}
+			assert.InDelta(t, tt.wantValue, c.GetParameterValue(modelPtr, "ParamAngleX"), 1e-5)
+			assert.Equal(t, tt.wantLooped, looped)
+			assert.Equal(t, tt.wantFinished, finished)
+			if !tt.wantFinished {
+				assert.Equal(t, tt.wantIter, mm.GetStates()[0].Iteration)
+			}
+		})
+	}
+}
+
+func TestMotionManagerEvents(t *testing.T) {
+	t.Parallel()
+
+	mtn := linearMotion()
+	mtn.UserData = []motion.UserData{
+		{Time: 0, Value: "start"},
+		{Time: 0.5, Value: "middle"},
+		{Time: 1, Value: "end"},
+	}
+
+	tests := []struct {
+		name    string
+		control func(mm *internalmotion.MotionManager, id int)
+		deltas  []float64
+		want    []string
+	}{
+		{
+			name:   "forward",
+			deltas: []float64{0.25, 0.25, 0.25},
+			want:   []string{"start", "middle"},
+		},
+		{
+			name:    "loop",
+			control: func(mm *internalmotion.MotionManager, id int) { mm.SetLoop(id, true, false) },
+			deltas:  []float64{0.6, 0.6, 0.6},
+			want:    []string{"start", "middle", "end", "start", "middle"},
+		},
+		{
+			name:    "reverse",
+			control: func(mm *internalmotion.MotionManager, id int) { mm.SetSpeed(id, -1); mm.Seek(id, 1) },
+			deltas:  []float64{0.6},
+			want:    []string{"end", "middle"},
+		},
+		{
+			name:    "seek",
+			control: func(mm *internalmotion.MotionManager, id int) { mm.Seek(id, 0.5) },
+			deltas:  []float64{0.1},
+			want:    []string{"middle"},
+		},
+		{
+			name:    "paused",
+			control: func(mm *internalmotion.MotionManager, id int) { mm.Pause(id) },
+			deltas:  []float64{0.6},
+			want:    []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			c, m := fake.LoadFixture(t)
+			modelPtr := m.ModelPtr
+			mm := internalmotion.NewMotionManager(c, modelPtr, func(id int) {})
+			got := []string{}
+			mm.SetEventHandler(func(id int, u motion.UserData) {
+				got = append(got, u.Value)
+			})
+			id := mm.Start(mtn)
+			if tt.control != nil {
+				tt.control(mm, id)
+			}
+			for _, d := range tt.deltas {
+				mm.Update(d)
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestMotionManagerBlend(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		blend  internalmotion.Blend
+		weight float64
+		want   float32
+	}{
+		{name: "override", blend: internalmotion.BlendOverride, weight: 1, want: 5},
+		{name: "override with weight", blend: internalmotion.BlendOverride, weight: 0.5, want: 4},
+		{name: "additive", blend: internalmotion.BlendAdditive, weight: 1, want: 8},
+		{name: "additive with weight", blend: internalmotion.BlendAdditive, weight: 0.5, want: 5.5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			c, m := fake.LoadFixture(t)
+			modelPtr := m.ModelPtr
+			mm := internalmotion.NewMotionManager(c, modelPtr, func(id int) {})
+			mm.SetBlend(tt.blend)
+			mm.SetManagerWeight(tt.weight)
+			mm.Start(linearMotion())
+			c.SetParameterValue(modelPtr, "ParamAngleX", 3)
+			mm.Update(0.5)
+			assert.InDelta(t, tt.want, c.GetParameterValue(modelPtr, "ParamAngleX"), 1e-5)
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package motion
+
+import "github.com/aethiopicuschan/cubism-go/core"
+
+// Storage of the parameter values between the updates
+// The values applied by the motions are saved and restored on the next update,
+// so that the additive motions do not accumulate and the fades start from the previous frame
+type ParameterStore struct {
+	core     core.Core
+	modelPtr uintptr
+	values   map[string]float32
+}
+
+func NewParameterStore(core core.Core, modelPtr uintptr) *ParameterStore {
+	return &ParameterStore{
+		core:     core,
+		modelPtr: modelPtr,
+		values:   make(map[string]float32),
+	}
+}
+
+func (s *ParameterStore) Save() {
+	// The IDs are collected once, and afterwards only the values are overwritten to avoid allocating
+	if len(s.values) == 0 {
+		for _, parameter := range s.core.GetParameters(s.modelPtr) {
+			s.values[parameter.Id] = parameter.Current
+		}
+		return
+	}
+	for id := range s.values {
+		s.values[id] = s.core.GetParameterValue(s.modelPtr, id)
+	}
+}
+
+func (s *ParameterStore) Load() {
+	for id, value := range s.values {
+		s.core.SetParameterValue(s.modelPtr, id, value)
+	}
+}
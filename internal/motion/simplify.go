@@ -0,0 +1,95 @@
+package motion
+
+import (
+	"math"
+
+	"github.com/aethiopicuschan/cubism-go/motion"
+)
+
+// Maximum number of samples spanned by a segment
+// Checking a segment costs time proportional to its length, so the bound keeps the simplification linear in the number of samples
+// A long still or linear take only gets a segment every few seconds more
+const maxSegmentSamples = 256
+
+// Append the segments approximating the samples within the tolerance to the curve
+// The curve must start at the first sample, and the times must be increasing
+// Each segment is the longest linear or Bezier segment starting from the end of the previous one, up to maxSegmentSamples
+func Simplify(b *motion.CurveBuilder, times, values []float64, tolerance float64) {
+	n := len(times)
+	for i := 0; i < n-1; {
+		end := i + 1
+		var control1, control2 motion.Point
+		bezier := false
+		for j := i + 2; j < n && j-i <= maxSegmentSamples; j++ {
+			if fitsLinear(times, values, i, j, tolerance) {
+				end, bezier = j, false
+				continue
+			}
+			if c1, c2, ok := fitBezier(times, values, i, j, tolerance); ok {
+				end, bezier = j, true
+				control1, control2 = c1, c2
+				continue
+			}
+			break
+		}
+		p := motion.Point{Time: times[end], Value: values[end]}
+		if bezier {
+			b.Bezier(control1, control2, p)
+		} else {
+			b.Linear(p)
+		}
+		i = end
+	}
+}
+
+// Check whether the line from the sample i to the sample j is within the tolerance of the samples between
+func fitsLinear(times, values []float64, i, j int, tolerance float64) bool {
+	for k := i + 1; k < j; k++ {
+		r := (times[k] - times[i]) / (times[j] - times[i])
+		if math.Abs(values[i]+(values[j]-values[i])*r-values[k]) > tolerance {
+			return false
+		}
+	}
+	return true
+}
+
+// Fit a Bezier segment from the sample i to the sample j by the least squares
+// The control points are placed at a third and two thirds of the time, so that the time is proportional to the parameter
+func fitBezier(times, values []float64, i, j int, tolerance float64) (control1, control2 motion.Point, ok bool) {
+	t0, t3 := times[i], times[j]
+	v0, v3 := values[i], values[j]
+	// Solve the normal equations for the values of the control points
+	var a11, a12, a22, r1, r2 float64
+	for k := i + 1; k < j; k++ {
+		u := (times[k] - t0) / (t3 - t0)
+		b0, b1, b2, b3 := bernstein(u)
+		rest := values[k] - b0*v0 - b3*v3
+		a11 += b1 * b1
+		a12 += b1 * b2
+		a22 += b2 * b2
+		r1 += b1 * rest
+		r2 += b2 * rest
+	}
+	det := a11*a22 - a12*a12
+	if math.Abs(det) < 1e-12 {
+		return
+	}
+	c1 := (r1*a22 - r2*a12) / det
+	c2 := (a11*r2 - a12*r1) / det
+	for k := i + 1; k < j; k++ {
+		u := (times[k] - t0) / (t3 - t0)
+		b0, b1, b2, b3 := bernstein(u)
+		if math.Abs(b0*v0+b1*c1+b2*c2+b3*v3-values[k]) > tolerance {
+			return
+		}
+	}
+	control1 = motion.Point{Time: t0 + (t3-t0)/3, Value: c1}
+	control2 = motion.Point{Time: t0 + (t3-t0)*2/3, Value: c2}
+	ok = true
+	return
+}
+
+func bernstein(u float64) (b0, b1, b2, b3 float64) {
+	m := 1 - u
+	return m * m * m, 3 * m * m * u, 3 * m * u * u, u * u * u
+}
@@ -0,0 +1,91 @@
+package motion_test
+
+import (
+	"math"
+	"math/rand/v2"
+	"testing"
+
+	"github.com/aethiopicuschan/cubism-go/core/fake"
+	internalmotion "github.com/aethiopicuschan/cubism-go/internal/motion"
+	"github.com/aethiopicuschan/cubism-go/motion"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Samples of a recorded parameter at 30 fps, moving smoothly with some noise and holding still at times
+func recordedSamples(seconds int) (times, values []float64) {
+	r := rand.New(rand.NewPCG(1, 2))
+	n := seconds*30 + 1
+	for i := 0; i < n; i++ {
+		t := float64(i) / 30
+		v := 20*math.Sin(t*0.7) + 8*math.Sin(t*2.3+1) + r.Float64()*0.2
+		// Hold still for two seconds out of every ten
+		if math.Mod(t, 10) < 2 {
+			v = 0
+		}
+		times = append(times, t)
+		values = append(values, v)
+	}
+	return
+}
+
+func TestSimplify(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		seconds   int
+		tolerance float64
+	}{
+		{name: "short", seconds: 5, tolerance: 0.3},
+		{name: "long", seconds: 120, tolerance: 0.3},
+		{name: "exact", seconds: 5, tolerance: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			times, values := recordedSamples(tt.seconds)
+			cb := motion.NewCurveBuilder("Parameter", "ParamAngleX", motion.Point{Time: times[0], Value: values[0]})
+			internalmotion.Simplify(cb, times, values, tt.tolerance)
+			mtn, err := motion.NewBuilder(times[len(times)-1]).Curve(cb).Build()
+			require.NoError(t, err)
+			segments := mtn.Curves[0].Segments
+			assert.Less(t, len(segments), len(times))
+
+			c, m := fake.LoadFixture(t)
+			modelPtr := m.ModelPtr
+			mm := internalmotion.NewMotionManager(c, modelPtr, func(id int) {})
+			id := mm.Start(mtn)
+			for i, tm := range times {
+				mm.Seek(id, tm)
+				mm.Update(0)
+				// The parameter is stored as float32
+				require.InDelta(t, values[i], c.GetParameterValue(modelPtr, "ParamAngleX"), tt.tolerance+1e-4, "sample %d", i)
+			}
+		})
+	}
+}
+
+func BenchmarkSimplify(b *testing.B) {
+	// Takes of five minutes
+	times, values := recordedSamples(300)
+	still := make([]float64, len(times))
+	benchmarks := []struct {
+		name   string
+		values []float64
+	}{
+		{name: "moving", values: values},
+		// Everything fits into long segments
+		{name: "still", values: still},
+	}
+
+	for _, bm := range benchmarks {
+		b.Run(bm.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				cb := motion.NewCurveBuilder("Parameter", "ParamAngleX", motion.Point{Time: times[0], Value: bm.values[0]})
+				internalmotion.Simplify(cb, times, bm.values, 0.3)
+			}
+		})
+	}
+}
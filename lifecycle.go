@@ -0,0 +1,61 @@
+package cubism
+
+import "sync"
+
+// State shared by the copies of a [Cubism]
+type lifecycle struct {
+	mu     sync.Mutex
+	closed bool
+	models map[*Model]struct{}
+}
+
+func newLifecycle() *lifecycle {
+	return &lifecycle{
+		models: make(map[*Model]struct{}),
+	}
+}
+
+func (l *lifecycle) isClosed() bool {
+	if l == nil {
+		return false
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.closed
+}
+
+// Register a model so that it is closed together with the Cubism
+func (l *lifecycle) add(m *Model) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.models[m] = struct{}{}
+}
+
+func (l *lifecycle) remove(m *Model) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.models, m)
+}
+
+// Mark as closed and return the models which are still open
+func (l *lifecycle) close() (models []*Model, ok bool) {
+	if l == nil {
+		return nil, true
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closed {
+		return nil, false
+	}
+	l.closed = true
+	for m := range l.models {
+		models = append(models, m)
+	}
+	return models, true
+}
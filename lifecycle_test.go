@@ -0,0 +1,50 @@
+package cubism_test
+
+import (
+	"testing"
+
+	"github.com/aethiopicuschan/cubism-go"
+	"github.com/aethiopicuschan/cubism-go/core/fake"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestModelClose(t *testing.T) {
+	t.Parallel()
+	m := loadFixture(t)
+
+	require.NoError(t, m.Close())
+	assert.True(t, m.IsClosed())
+	assert.ErrorIs(t, m.Close(), cubism.ErrClosed)
+
+	_, err := m.GetDrawable("Mouth")
+	assert.ErrorIs(t, err, cubism.ErrClosed)
+	_, err = m.PlayMotion("Idle", 0, false)
+	assert.ErrorIs(t, err, cubism.ErrClosed)
+	assert.ErrorIs(t, m.PreloadMotions(), cubism.ErrClosed)
+	assert.Nil(t, m.GetParameters())
+	assert.Zero(t, m.GetParameterValue("ParamAngleX"))
+	assert.NotPanics(t, func() {
+		m.SetParameterValue("ParamAngleX", 1)
+		m.StopMotion(0)
+		m.Update(0.1)
+	})
+}
+
+func TestCubismClose(t *testing.T) {
+	t.Parallel()
+	path, err := fake.WriteFixture(t.TempDir())
+	require.NoError(t, err)
+	csm := cubism.NewCubismFromCore(fake.NewCore(fake.FixtureModel()))
+	m1, err := csm.LoadModel(path)
+	require.NoError(t, err)
+	m2, err := csm.LoadModel(path)
+	require.NoError(t, err)
+	require.NoError(t, m2.Close())
+
+	require.NoError(t, csm.Close())
+	assert.True(t, m1.IsClosed())
+	assert.ErrorIs(t, csm.Close(), cubism.ErrClosed)
+	_, err = csm.LoadModel(path)
+	assert.ErrorIs(t, err, cubism.ErrClosed)
+}
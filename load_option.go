@@ -0,0 +1,67 @@
+package cubism
+
+import "errors"
+
+// Options for loading a model
+type LoadOption struct {
+	lenient          bool
+	skipSounds       bool
+	skipMotions      bool
+	lazyMotions      bool
+	motionCacheLimit int
+}
+
+// Skip the optional files that cannot be loaded instead of failing
+// model3.json and moc3 are always required
+// The skipped files are reported by [Model.GetWarnings]
+func WithLenient() func(*LoadOption) {
+	return func(o *LoadOption) {
+		o.lenient = true
+	}
+}
+
+// Do not load the sounds of the motions
+// The motions are played silently
+func WithoutSounds() func(*LoadOption) {
+	return func(o *LoadOption) {
+		o.skipSounds = true
+	}
+}
+
+// Do not load the motions
+// This is useful for thumbnails and other cases where the model is not animated
+func WithoutMotions() func(*LoadOption) {
+	return func(o *LoadOption) {
+		o.skipMotions = true
+	}
+}
+
+// Load the motions on first use instead of loading all of them with the model
+// Use [Model.PreloadMotions] to load specific groups in advance
+func WithLazyMotions() func(*LoadOption) {
+	return func(o *LoadOption) {
+		o.lazyMotions = true
+	}
+}
+
+// Limit the number of motions kept loaded in lazy mode
+// The least recently used motions are discarded first, and preloaded motions are not counted
+// The sounds of the discarded motions are released, after they finish if they are playing
+func WithMotionCacheLimit(limit int) func(*LoadOption) {
+	return func(o *LoadOption) {
+		o.motionCacheLimit = limit
+	}
+}
+
+// Record the error as a warning of the model if lenient loading is enabled
+// Otherwise the error is returned as is
+func (o *LoadOption) tolerate(m *Model, err error) error {
+	var loadErr *LoadError
+	if !o.lenient || !errors.As(err, &loadErr) {
+		return err
+	}
+	m.warningsMu.Lock()
+	defer m.warningsMu.Unlock()
+	m.warnings = append(m.warnings, loadErr)
+	return nil
+}
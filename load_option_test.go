@@ -0,0 +1,324 @@
+package cubism_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aethiopicuschan/cubism-go"
+	"github.com/aethiopicuschan/cubism-go/core/fake"
+	"github.com/aethiopicuschan/cubism-go/sound"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithLenient(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	path, err := fake.WriteFixture(dir)
+	require.NoError(t, err)
+	require.NoError(t, os.Remove(filepath.Join(dir, "motions", "Tap.motion3.json")))
+	require.NoError(t, os.Remove(filepath.Join(dir, "expressions", "Smile.exp3.json")))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "Fake.cdi3.json"), []byte("broken"), 0o644))
+
+	csm := cubism.NewCubismFromCore(fake.NewCore(fake.FixtureModel()))
+	_, err = csm.LoadModel(path)
+	require.Error(t, err)
+
+	m, err := csm.LoadModel(path, cubism.WithLenient())
+	require.NoError(t, err)
+	kinds := []cubism.FileKind{}
+	for _, w := range m.GetWarnings() {
+		kinds = append(kinds, w.Kind)
+	}
+	assert.ElementsMatch(t, []cubism.FileKind{cubism.FileDisplayInfo, cubism.FileExpression, cubism.FileMotion}, kinds)
+	assert.Len(t, m.GetMotions("Idle"), 1)
+	assert.Empty(t, m.GetMotions("TapBody"))
+	// The display names are not available
+	assert.Empty(t, m.GetParameters()[0].Name)
+
+	// The moc3 file is always required
+	require.NoError(t, os.Remove(filepath.Join(dir, "Fake.moc3")))
+	_, err = csm.LoadModel(path, cubism.WithLenient())
+	assert.Error(t, err)
+}
+
+func TestWithLenientIndices(t *testing.T) {
+	t.Parallel()
+	path, err := fake.WriteFixture(t.TempDir())
+	require.NoError(t, err)
+	b, err := os.ReadFile(path)
+	require.NoError(t, err)
+	// Make the first motion of the group missing
+	broken := strings.Replace(string(b), `"Greeting": [
+				{
+					"File": "motions/Idle.motion3.json"`, `"Greeting": [
+				{
+					"File": "motions/Missing.motion3.json"`, 1)
+	require.NotEqual(t, string(b), broken)
+	require.NoError(t, os.WriteFile(path, []byte(broken), 0o644))
+
+	csm := cubism.NewCubismFromCore(fake.NewCore(fake.FixtureModel()))
+	tests := []struct {
+		name string
+		opts []func(*cubism.LoadOption)
+	}{
+		{name: "eager", opts: []func(*cubism.LoadOption){cubism.WithLenient()}},
+		{name: "lazy", opts: []func(*cubism.LoadOption){cubism.WithLenient(), cubism.WithLazyMotions()}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			m, err := csm.LoadModel(path, tt.opts...)
+			require.NoError(t, err)
+			// The indices are the same as in model3.json
+			assert.Equal(t, 3, m.GetMotionCount("Greeting"))
+			assert.Len(t, m.GetMotions("Greeting"), 2)
+			_, err = m.PlayMotion("Greeting", 0, false)
+			var loadErr *cubism.LoadError
+			require.ErrorAs(t, err, &loadErr)
+			assert.Equal(t, cubism.FileMotion, loadErr.Kind)
+			_, err = m.PlayMotion("Greeting", 2, false)
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestWithLenientLazyConcurrent(t *testing.T) {
+	t.Parallel()
+	path, err := fake.WriteFixture(t.TempDir())
+	require.NoError(t, err)
+
+	csm := cubism.NewCubismFromCore(fake.NewCore(fake.FixtureModel()))
+	csm.LoadSound = func(fp string) (s sound.Sound, err error) {
+		return nil, os.ErrNotExist
+	}
+	m, err := csm.LoadModel(path, cubism.WithLenient(), cubism.WithLazyMotions())
+	require.NoError(t, err)
+	// The sound warnings are recorded while loading lazily and read concurrently
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			m.GetMotions("TapBody")
+		}()
+		go func() {
+			defer wg.Done()
+			m.GetWarnings()
+		}()
+	}
+	wg.Wait()
+	warnings := m.GetWarnings()
+	require.NotEmpty(t, warnings)
+	// The returned slice is a copy
+	warnings[0] = nil
+	assert.NotNil(t, m.GetWarnings()[0])
+}
+
+func TestWithLenientSound(t *testing.T) {
+	t.Parallel()
+	path, err := fake.WriteFixture(t.TempDir())
+	require.NoError(t, err)
+
+	csm := cubism.NewCubismFromCore(fake.NewCore(fake.FixtureModel()))
+	csm.LoadSound = func(fp string) (s sound.Sound, err error) {
+		return nil, os.ErrNotExist
+	}
+	_, err = csm.LoadModel(path)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	m, err := csm.LoadModel(path, cubism.WithLenient())
+	require.NoError(t, err)
+	require.Len(t, m.GetWarnings(), 1)
+	assert.Equal(t, cubism.FileSound, m.GetWarnings()[0].Kind)
+	// The motion is kept without the sound
+	require.Len(t, m.GetMotions("TapBody"), 1)
+	assert.NotNil(t, m.GetMotions("TapBody")[0].LoadedSound)
+}
+
+func TestWithoutSounds(t *testing.T) {
+	t.Parallel()
+	path, err := fake.WriteFixture(t.TempDir())
+	require.NoError(t, err)
+
+	called := false
+	csm := cubism.NewCubismFromCore(fake.NewCore(fake.FixtureModel()))
+	csm.LoadSound = func(fp string) (s sound.Sound, err error) {
+		called = true
+		return nil, os.ErrNotExist
+	}
+	m, err := csm.LoadModel(path, cubism.WithoutSounds())
+	require.NoError(t, err)
+	assert.False(t, called)
+	assert.Len(t, m.GetMotions("TapBody"), 1)
+}
+
+func TestWithoutMotions(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	path, err := fake.WriteFixture(dir)
+	require.NoError(t, err)
+	require.NoError(t, os.Remove(filepath.Join(dir, "motions", "Tap.motion3.json")))
+
+	csm := cubism.NewCubismFromCore(fake.NewCore(fake.FixtureModel()))
+	m, err := csm.LoadModel(path, cubism.WithoutMotions())
+	require.NoError(t, err)
+	assert.Empty(t, m.GetMotionGroupNames())
+	assert.Empty(t, m.GetWarnings())
+}
+
+func TestWithLazyMotions(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	path, err := fake.WriteFixture(dir)
+	require.NoError(t, err)
+
+	csm := cubism.NewCubismFromCore(fake.NewCore(fake.FixtureModel()))
+	m, err := csm.LoadModel(path, cubism.WithLazyMotions(), cubism.WithMotionCacheLimit(1))
+	require.NoError(t, err)
+	assert.Equal(t, 1, m.GetMotionCount("Idle"))
+	assert.False(t, m.IsMotionLoaded("Idle", 0))
+	assert.False(t, m.IsMotionLoaded("TapBody", 0))
+
+	// The motion is loaded on first play
+	_, err = m.PlayMotion("Idle", 0, true)
+	require.NoError(t, err)
+	assert.True(t, m.IsMotionLoaded("Idle", 0))
+
+	// The least recently used motion is evicted
+	_, err = m.PlayMotion("TapBody", 0, false)
+	require.NoError(t, err)
+	assert.True(t, m.IsMotionLoaded("TapBody", 0))
+	assert.False(t, m.IsMotionLoaded("Idle", 0))
+
+	// Preloaded motions are kept regardless of the limit
+	require.NoError(t, m.PreloadMotions("Idle"))
+	_, err = m.PlayMotion("TapBody", 0, false)
+	require.NoError(t, err)
+	assert.True(t, m.IsMotionLoaded("Idle", 0))
+	assert.True(t, m.IsMotionLoaded("TapBody", 0))
+	assert.Error(t, m.PreloadMotions("Unknown"))
+
+	// Errors are reported when the motion is played
+	m, err = csm.LoadModel(path, cubism.WithLazyMotions())
+	require.NoError(t, err)
+	require.NoError(t, os.Remove(filepath.Join(dir, "motions", "Tap.motion3.json")))
+	_, err = m.PlayMotion("TapBody", 0, false)
+	var loadErr *cubism.LoadError
+	require.ErrorAs(t, err, &loadErr)
+	assert.Equal(t, cubism.FileMotion, loadErr.Kind)
+}
+
+type releasedSound struct {
+	fp       string
+	released bool
+	// Whether the sound was played or stopped after being released
+	usedAfterRelease bool
+}
+
+func (s *releasedSound) Play() error {
+	s.usedAfterRelease = s.usedAfterRelease || s.released
+	return nil
+}
+
+func (s *releasedSound) Close() {
+	s.usedAfterRelease = s.usedAfterRelease || s.released
+}
+
+func (s *releasedSound) Release() error {
+	s.released = true
+	return nil
+}
+
+func TestWithMotionCacheLimitRelease(t *testing.T) {
+	t.Parallel()
+	path, err := fake.WriteFixture(t.TempDir())
+	require.NoError(t, err)
+
+	sounds := []*releasedSound{}
+	csm := cubism.NewCubismFromCore(fake.NewCore(fake.FixtureModel()))
+	csm.LoadSound = func(fp string) (s sound.Sound, err error) {
+		rs := &releasedSound{fp: fp}
+		sounds = append(sounds, rs)
+		return rs, nil
+	}
+	m, err := csm.LoadModel(path, cubism.WithLazyMotions(), cubism.WithMotionCacheLimit(1))
+	require.NoError(t, err)
+
+	// The sound of a motion evicted while playing is released once it is stopped
+	id, err := m.PlayMotion("TapBody", 0, true)
+	require.NoError(t, err)
+	require.Len(t, sounds, 1)
+	_, err = m.PlayMotion("Idle", 0, true)
+	require.NoError(t, err)
+	assert.False(t, m.IsMotionLoaded("TapBody", 0))
+	assert.False(t, sounds[0].released)
+	m.StopMotion(id)
+	assert.True(t, sounds[0].released)
+
+	// The sound of a motion which is not playing is released when it is evicted
+	id, err = m.PlayMotion("TapBody", 0, true)
+	require.NoError(t, err)
+	require.Len(t, sounds, 2)
+	m.StopMotion(id)
+	assert.False(t, sounds[1].released)
+	_, err = m.PlayMotion("Idle", 0, true)
+	require.NoError(t, err)
+	assert.True(t, sounds[1].released)
+}
+
+// Give each motion of the Greeting group a sound
+func addGreetingSounds(t *testing.T, path string) {
+	t.Helper()
+	b, err := os.ReadFile(path)
+	require.NoError(t, err)
+	var mj map[string]any
+	require.NoError(t, json.Unmarshal(b, &mj))
+	greeting := mj["FileReferences"].(map[string]any)["Motions"].(map[string]any)["Greeting"].([]any)
+	for i, g := range greeting {
+		g.(map[string]any)["Sound"] = fmt.Sprintf("sounds/Greeting%d.wav", i)
+	}
+	b, err = json.Marshal(mj)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, b, 0o644))
+}
+
+func TestWithMotionCacheLimitGetMotions(t *testing.T) {
+	t.Parallel()
+	path, err := fake.WriteFixture(t.TempDir())
+	require.NoError(t, err)
+	addGreetingSounds(t, path)
+
+	sounds := map[string]*releasedSound{}
+	csm := cubism.NewCubismFromCore(fake.NewCore(fake.FixtureModel()))
+	csm.LoadSound = func(fp string) (s sound.Sound, err error) {
+		rs := &releasedSound{fp: fp}
+		sounds[filepath.Base(fp)] = rs
+		return rs, nil
+	}
+	m, err := csm.LoadModel(path, cubism.WithLazyMotions(), cubism.WithMotionCacheLimit(1))
+	require.NoError(t, err)
+
+	// The group is larger than the cache, so the playing motion is evicted while getting it
+	id, err := m.PlayMotion("TapBody", 0, true)
+	require.NoError(t, err)
+	assert.Len(t, m.GetMotions("Greeting"), 3)
+	assert.False(t, sounds["Tap.wav"].released)
+	assert.True(t, sounds["Greeting0.wav"].released)
+	assert.True(t, sounds["Greeting1.wav"].released)
+	assert.False(t, sounds["Greeting2.wav"].released)
+	// The sound is retriggered on each loop
+	for i := 0; i < 30; i++ {
+		m.Update(0.1)
+	}
+	m.StopMotion(id)
+	assert.True(t, sounds["Tap.wav"].released)
+	for fp, s := range sounds {
+		assert.False(t, s.usedAfterRelease, fp)
+	}
+}
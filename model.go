@@ -2,33 +2,68 @@ package cubism
 
 import (
 	"fmt"
+	"slices"
+	"sync"
 
+	"github.com/aethiopicuschan/cubism-go/core"
+	"github.com/aethiopicuschan/cubism-go/core/drawable"
+	"github.com/aethiopicuschan/cubism-go/core/moc"
+	"github.com/aethiopicuschan/cubism-go/core/parameter"
 	"github.com/aethiopicuschan/cubism-go/internal/blink"
-	"github.com/aethiopicuschan/cubism-go/internal/core"
-	"github.com/aethiopicuschan/cubism-go/internal/core/drawable"
-	"github.com/aethiopicuschan/cubism-go/internal/core/moc"
-	"github.com/aethiopicuschan/cubism-go/internal/core/parameter"
 	"github.com/aethiopicuschan/cubism-go/internal/model"
-	"github.com/aethiopicuschan/cubism-go/internal/motion"
+	internalmotion "github.com/aethiopicuschan/cubism-go/internal/motion"
+	"github.com/aethiopicuschan/cubism-go/motion"
+	"github.com/aethiopicuschan/cubism-go/sound/disabled"
 )
 
 // A model struct
+//
+// The methods are safe for concurrent use. [Model.Update] and the setters lock the model exclusively,
+// while the getters share a read lock. The vertex positions returned by [Model.GetDrawables] are buffers
+// which the next [Model.Update] overwrites, so a goroutine reading while another one updates
+// should use [Model.Snapshot] or [Model.CopyDrawables] instead.
 type Model struct {
+	// Guards the mutable state below
+	mu sync.RWMutex
 	// Internally required
-	motionManager *motion.MotionManager
-	loopMotions   []int
+	lifecycle *lifecycle
+	closed    bool
+	// Motion layers in the order of composition, the first one is the base layer
+	motionLayers   []*motionLayer
+	lastMotionId   int
+	parameterStore *internalmotion.ParameterStore
+	// Index of the motion played last in each group
+	lastMotionIndices map[string]int
+	// Group and index of the playing motions by ID
+	playingMotions map[int]motionKey
+	// Recorders sampling on each update
+	recorders []*Recorder
+	// Whether a motion was applied on the previous update
+	motionsActive bool
 	blinkManager  *blink.BlinkManager
+	timeScale     float64
+	// Callbacks of the motions
+	onMotionEvent    func(MotionEvent)
+	onMotionStarted  func(int)
+	onMotionFinished func(int)
+	onMotionLooped   func(int)
+	motionWaiters    map[int][]chan struct{}
+	// Callbacks to be called once the model is unlocked
+	pending []func()
 	// Read-only via getters
 	version       int
 	core          core.Core
 	moc           moc.Moc
 	opacity       float32
 	textures      []string
-	motions       map[string][]motion.Motion
+	motions       *motionStore
 	sortedIndices []int
+	frame         uint64
 	drawables     []Drawable
-	drawablesMap  map[string]Drawable
-	hitAreas      []model.HitArea
+	// Index of the drawables by ID
+	drawablesMap map[string]int
+	parts        []Part
+	hitAreas     []HitArea
 	// Not exposed externally
 	groups   []model.Group
 	physics  model.PhysicsJson
@@ -36,6 +71,42 @@ type Model struct {
 	cdi      model.CdiJson
 	exps     []model.ExpJson
 	userdata model.UserDataJson
+	// Guards warnings, which lazy loading appends to while the model is only read locked
+	warningsMu sync.Mutex
+	warnings   []*LoadError
+}
+
+// Close the model and release its resources
+// The sounds of the motions are stopped and released if they implement [sound.Releaser]
+// Afterwards the getters return empty values, the setters do nothing and the other methods return [ErrClosed]
+func (m *Model) Close() (err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.closed {
+		return ErrClosed
+	}
+	m.closed = true
+	m.releaseMotionWaiters()
+	m.pending = nil
+	m.motionLayers = nil
+	m.recorders = nil
+	m.playingMotions = nil
+	m.blinkManager = nil
+	err = m.motions.release()
+	core.ReleaseMoc(m.core, m.moc)
+	m.moc = moc.Moc{}
+	m.sortedIndices = nil
+	m.drawables = nil
+	m.drawablesMap = nil
+	m.lifecycle.remove(m)
+	return
+}
+
+// Check whether the model has been closed
+func (m *Model) IsClosed() bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.closed
 }
 
 // Get the version of the model
@@ -43,13 +114,24 @@ func (m *Model) GetVersion() int {
 	return m.version
 }
 
+// Get the errors skipped while loading the model with [WithLenient]
+func (m *Model) GetWarnings() (warnings []*LoadError) {
+	m.warningsMu.Lock()
+	defer m.warningsMu.Unlock()
+	warnings = slices.Clone(m.warnings)
+	return
+}
+
 // Get the core
+// Calls through it bypass the locking of the model, so they must not run concurrently with its methods
 func (m *Model) GetCore() core.Core {
 	return m.core
 }
 
 // Get the moc
 func (m *Model) GetMoc() moc.Moc {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
 	return m.moc
 }
 
@@ -64,90 +146,471 @@ func (m *Model) GetTextures() []string {
 }
 
 // Get the sorted drawing order indices
+// The slice is a copy, as the model updates its own in place
 func (m *Model) GetSortedIndices() []int {
-	return m.sortedIndices
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return append([]int(nil), m.sortedIndices...)
+}
+
+// Get the number of times the model has been updated
+func (m *Model) GetFrame() uint64 {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.frame
 }
 
 // Get the drawables
-func (m *Model) GetDrawables() []Drawable {
-	return m.drawables
+// The slice is a copy, but the vertex positions are buffers of the model reused across frames
+// Use [Model.CopyDrawables] to keep them beyond the next [Model.Update]
+func (m *Model) GetDrawables() (ds []Drawable) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.drawables == nil {
+		return
+	}
+	ds = make([]Drawable, len(m.drawables))
+	copy(ds, m.drawables)
+	return
+}
+
+// Copy the drawables into dst and return it
+// The vertex positions are copied into the buffers of dst, which are grown if necessary,
+// so passing the result of the previous call avoids allocating on each frame
+func (m *Model) CopyDrawables(dst []Drawable) []Drawable {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return copyDrawables(dst, m.drawables)
+}
+
+func copyDrawables(dst, src []Drawable) []Drawable {
+	dst = slices.Grow(dst[:0], len(src))[:len(src)]
+	for i, d := range src {
+		positions := append(dst[i].VertexPositions[:0], d.VertexPositions...)
+		dst[i] = d
+		dst[i].VertexPositions = positions
+	}
+	return dst
+}
+
+// Get the vertex positions of the drawables without copying
+// The slices refer to the memory of the Cubism Core and are only valid until the next [Model.Update] or [Model.Close]
+// They must not be modified, nor read while another goroutine updates the model
+func (m *Model) UnsafeVertexPositions() [][]drawable.Vector2 {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.closed {
+		return nil
+	}
+	return m.core.GetVertexPositions(m.moc.ModelPtr)
+}
+
+// Get the opacities of the drawables without copying
+// The slice refers to the memory of the Cubism Core and is only valid until the next [Model.Update] or [Model.Close]
+// It must not be modified, nor read while another goroutine updates the model
+func (m *Model) UnsafeOpacities() []float32 {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.closed {
+		return nil
+	}
+	return m.core.GetOpacities(m.moc.ModelPtr)
 }
 
 // Get the Drawable with the specified ID
 func (m *Model) GetDrawable(id string) (d Drawable, err error) {
-	if d, ok := m.drawablesMap[id]; ok {
-		return d, nil
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.closed {
+		err = ErrClosed
+		return
+	}
+	if i, ok := m.drawablesMap[id]; ok {
+		return m.drawables[i], nil
+	}
+	err = fmt.Errorf("%w: %s", ErrDrawableNotFound, id)
+	return
+}
+
+// Get the parts
+// The opacities are the current values
+func (m *Model) GetParts() (parts []Part) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.closed {
+		return
+	}
+	ps := core.GetParts(m.core, m.moc.ModelPtr)
+	parts = make([]Part, len(m.parts))
+	copy(parts, m.parts)
+	for i := range parts {
+		parts[i].Opacity = ps[i].Opacity
+	}
+	return
+}
+
+// Get the Part with the specified ID
+func (m *Model) GetPart(id string) (p Part, err error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.closed {
+		err = ErrClosed
+		return
 	}
-	err = fmt.Errorf("Drawable not found: %s", id)
+	for _, p := range m.parts {
+		if p.Id == id {
+			p.Opacity = core.GetPartOpacity(m.core, m.moc.ModelPtr, id)
+			return p, nil
+		}
+	}
+	err = fmt.Errorf("%w: %s", ErrPartNotFound, id)
 	return
 }
 
+// Get the opacity of the part
+func (m *Model) GetPartOpacity(id string) float32 {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.closed {
+		return 0
+	}
+	return core.GetPartOpacity(m.core, m.moc.ModelPtr, id)
+}
+
+// Set the opacity of the part
+func (m *Model) SetPartOpacity(id string, value float32) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.closed {
+		return
+	}
+	m.core.SetPartOpacity(m.moc.ModelPtr, id, value)
+}
+
 // Get the list of hit areas
-func (m *Model) GetHitAreas() []model.HitArea {
+func (m *Model) GetHitAreas() []HitArea {
 	return m.hitAreas
 }
 
 // Get the list of parameters
-func (m *Model) GetParameters() []parameter.Parameter {
-	return m.core.GetParameters(m.moc.ModelPtr)
+// The display names and groups are filled in from cdi3.json if it exists
+func (m *Model) GetParameters() (ps []parameter.Parameter) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.closed {
+		return
+	}
+	ps = m.core.GetParameters(m.moc.ModelPtr)
+	for i := range ps {
+		ps[i].Name, ps[i].GroupId = m.cdi.GetParameterName(ps[i].Id)
+		if ps[i].GroupId != "" {
+			ps[i].GroupName = m.cdi.GetParameterGroupName(ps[i].GroupId)
+		}
+	}
+	return
 }
 
 // Get the value of the parameter
 func (m *Model) GetParameterValue(id string) float32 {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.closed {
+		return 0
+	}
 	return m.core.GetParameterValue(m.moc.ModelPtr, id)
 }
 
 // Set the value of the parameter
 func (m *Model) SetParameterValue(id string, value float32) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.closed {
+		return
+	}
 	m.core.SetParameterValue(m.moc.ModelPtr, id, value)
 }
 
 // Get the list of motion group names
 func (m *Model) GetMotionGroupNames() (names []string) {
-	for k := range m.motions {
+	for k := range m.motions.refs {
 		names = append(names, k)
 	}
 	return
 }
 
+// Get the number of motions in the group
+// Unlike [Model.GetMotions], this does not load the motions
+func (m *Model) GetMotionCount(groupName string) int {
+	return len(m.motions.refs[groupName])
+}
+
 // Get the list of motions in the group
-func (m *Model) GetMotions(groupName string) []motion.Motion {
-	return m.motions[groupName]
-}
-
-// Play a motion
-func (m *Model) PlayMotion(groupName string, index int, loop bool) (id int) {
-	if m.motionManager == nil {
-		m.motionManager = motion.NewMotionManager(m.core, m.moc.ModelPtr, func(id int) {
-			for _, loopId := range m.loopMotions {
-				if id == loopId {
-					m.motionManager.Reset(id)
-					return
-				}
-			}
-			m.motionManager.Close(id)
-		})
-	}
-	id = m.motionManager.Start(m.motions[groupName][index])
+// With [WithLazyMotions], the motions are loaded if necessary
+// The motions that fail to load are omitted, so use [Model.GetMotionCount] for the indices
+// With [WithMotionCacheLimit], the sounds of the returned motions are released once the cache discards them
+func (m *Model) GetMotions(groupName string) (motions []motion.Motion) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.closed {
+		return
+	}
+	motions = []motion.Motion{}
+	for i := range m.motions.refs[groupName] {
+		mtn, err := m.motions.get(groupName, i)
+		if err != nil {
+			continue
+		}
+		motions = append(motions, mtn)
+	}
+	m.motions.settle()
+	return
+}
+
+// Check whether the motion is loaded
+// It is always true unless [WithLazyMotions] is used
+func (m *Model) IsMotionLoaded(groupName string, index int) bool {
+	return m.motions.isLoaded(groupName, index)
+}
+
+// Load the motions of the groups in advance and keep them loaded
+// If no group is specified, all the groups are loaded
+func (m *Model) PreloadMotions(groupNames ...string) error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.closed {
+		return ErrClosed
+	}
+	if len(groupNames) == 0 {
+		for name := range m.motions.refs {
+			groupNames = append(groupNames, name)
+		}
+	}
+	return m.motions.preload(groupNames...)
+}
+
+// Play a motion on the base layer
+// The motion loops if loop is true, and otherwise if Meta.Loop of its motion3.json is true
+// Use [Model.PlayMotionWithOptions] with [WithLoop] to stop a motion of Meta.Loop from looping
+// With [WithLazyMotions], the motion is loaded on first play and the error is returned if it fails
+// An unknown group or index returns [ErrMotionGroupNotFound] or [ErrMotionIndexOutOfRange]
+func (m *Model) PlayMotion(groupName string, index int, loop bool) (id int, err error) {
+	return m.PlayMotionOnLayer(BaseMotionLayer, groupName, index, loop)
+}
+
+// Play a motion on the layer added by [Model.AddMotionLayer]
+// The IDs of the motions are unique across the layers
+// The loop is decided as by [Model.PlayMotion]
+func (m *Model) PlayMotionOnLayer(layerName string, groupName string, index int, loop bool) (id int, err error) {
+	opts := []func(*PlayOption){WithLayer(layerName)}
 	if loop {
-		m.loopMotions = append(m.loopMotions, id)
+		opts = append(opts, WithLoop(true))
+	}
+	return m.PlayMotionWithOptions(groupName, index, opts...)
+}
+
+// Play a motion with options
+// Without [WithLoop], the motion loops if Meta.Loop of its motion3.json is true
+func (m *Model) PlayMotionWithOptions(groupName string, index int, opts ...func(*PlayOption)) (id int, err error) {
+	id, pending, err := m.playMotion(groupName, func() (int, error) { return index, nil }, newPlayOption(opts))
+	runPending(pending)
+	return
+}
+
+// Play the motion of the group chosen by selectIndex under the lock
+func (m *Model) playMotion(groupName string, selectIndex func() (int, error), o *PlayOption) (id int, pending []func(), err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.closed {
+		err = ErrClosed
+		return
+	}
+	layer, err := m.findMotionLayer(o.layerName)
+	if err != nil {
+		return
+	}
+	index, err := selectIndex()
+	if err != nil {
+		return
+	}
+	mtn, err := m.motions.get(groupName, index)
+	if err != nil {
+		return
+	}
+	if m.lastMotionIndices == nil {
+		m.lastMotionIndices = make(map[string]int)
+	}
+	m.lastMotionIndices[groupName] = index
+	if m.playingMotions == nil {
+		m.playingMotions = make(map[int]motionKey)
+	}
+	id = m.startMotion(layer, mtn, o)
+	m.playingMotions[id] = motionKey{group: groupName, index: index}
+	m.motions.settle()
+	pending = m.takePending()
+	return
+}
+
+// Play a motion which is not in model3.json, such as one made by [motion.Builder] or [Recorder]
+// Without [WithLoop], the motion loops if its Meta.Loop is true
+// Its sound is not played unless LoadedSound is set, and it is left out of [Model.SaveState]
+func (m *Model) PlayMotionData(mtn motion.Motion, opts ...func(*PlayOption)) (id int, err error) {
+	id, pending, err := m.playMotionData(mtn, newPlayOption(opts))
+	runPending(pending)
+	return
+}
+
+func (m *Model) playMotionData(mtn motion.Motion, o *PlayOption) (id int, pending []func(), err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.closed {
+		err = ErrClosed
+		return
+	}
+	layer, err := m.findMotionLayer(o.layerName)
+	if err != nil {
+		return
+	}
+	if mtn.LoadedSound == nil {
+		mtn.LoadedSound, _ = disabled.LoadSound(mtn.Sound)
+	}
+	id = m.startMotion(layer, mtn, o)
+	pending = m.takePending()
+	return
+}
+
+// Start the motion on the layer with a new ID
+// The model must be locked
+func (m *Model) startMotion(layer *motionLayer, mtn motion.Motion, o *PlayOption) (id int) {
+	m.lastMotionId++
+	id = layer.manager.StartWithId(mtn, m.lastMotionId)
+	loop := mtn.Meta.Loop
+	if o.loop != nil {
+		loop = *o.loop
+	}
+	layer.manager.SetLoop(id, loop, o.fadeInOnLoop)
+	if f := m.onMotionStarted; f != nil {
+		m.notify(func() { f(id) })
 	}
 	return
 }
 
 // Stop a motion
 func (m *Model) StopMotion(id int) {
-	for i, loopId := range m.loopMotions {
-		if id == loopId {
-			m.loopMotions = append(m.loopMotions[:i], m.loopMotions[i+1:]...)
-			break
-		}
+	runPending(m.stopMotion(id))
+}
+
+func (m *Model) stopMotion(id int) (pending []func()) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	mm := m.findMotion(id)
+	if mm == nil {
+		return
+	}
+	m.closeMotion(mm, id)
+	return m.takePending()
+}
+
+// Call f with the motion manager if the motion is playing
+func (m *Model) controlMotion(id int, f func(*internalmotion.MotionManager) bool) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.closed {
+		return ErrClosed
 	}
-	m.motionManager.Close(id)
+	mm := m.findMotion(id)
+	if mm == nil || !f(mm) {
+		return fmt.Errorf("%w: %d", ErrMotionNotFound, id)
+	}
+	return nil
+}
+
+// Pause a motion
+// The parameters keep the values of the current frame, and the sound is stopped
+func (m *Model) PauseMotion(id int) error {
+	return m.controlMotion(id, func(mm *internalmotion.MotionManager) bool {
+		return mm.Pause(id)
+	})
+}
+
+// Resume a paused motion
+// Its sound stays stopped, as it cannot be started in the middle
+func (m *Model) ResumeMotion(id int) error {
+	return m.controlMotion(id, func(mm *internalmotion.MotionManager) bool {
+		return mm.Resume(id)
+	})
+}
+
+// Check whether the motion is paused
+func (m *Model) IsMotionPaused(id int) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	mm := m.findMotion(id)
+	return mm != nil && mm.IsPaused(id)
+}
+
+// Move a motion to the time in seconds
+// The time is clamped to the duration of the motion, and the frame is applied on the next [Model.Update],
+// so calling Update(0) after seeking a paused motion previews that frame
+func (m *Model) SeekMotion(id int, t float64) error {
+	return m.controlMotion(id, func(mm *internalmotion.MotionManager) bool {
+		return mm.Seek(id, t)
+	})
+}
+
+// Get the current time of a motion in seconds
+func (m *Model) GetMotionTime(id int) (t float64, err error) {
+	err = m.controlMotion(id, func(mm *internalmotion.MotionManager) (ok bool) {
+		t, ok = mm.GetTime(id)
+		return
+	})
+	return
+}
+
+// Set the playback speed of a motion
+// 1 is the normal speed, and negative values play the motion in reverse
+// A motion reversed at its beginning, such as one just played, starts from its end
+// A reversed motion finishes when it reaches the beginning
+func (m *Model) SetMotionSpeed(id int, speed float64) error {
+	return m.controlMotion(id, func(mm *internalmotion.MotionManager) bool {
+		return mm.SetSpeed(id, speed)
+	})
+}
+
+// Set the blend weight of a motion
+// The weight is clamped to [0, 1], where 0 leaves the parameters unchanged and 1 applies the motion fully
+func (m *Model) SetMotionWeight(id int, weight float64) error {
+	return m.controlMotion(id, func(mm *internalmotion.MotionManager) bool {
+		return mm.SetWeight(id, weight)
+	})
+}
+
+// Set the scale of the time passed to [Model.Update]
+// It applies to the motions and the auto blink, and the default is 1
+func (m *Model) SetTimeScale(scale float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.timeScale = scale
+}
+
+// Get the scale of the time passed to [Model.Update]
+func (m *Model) GetTimeScale() float64 {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.timeScale
 }
 
 // Enable Auto Blink
 func (m *Model) EnableAutoBlink() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.closed {
+		return
+	}
+	m.enableAutoBlink()
+}
+
+// The model must be locked
+func (m *Model) enableAutoBlink() {
 	for _, group := range m.groups {
 		if group.Name == "EyeBlink" {
 			m.blinkManager = blink.NewBlinkManager(m.core, m.moc.ModelPtr, group.Ids)
@@ -158,72 +621,65 @@ func (m *Model) EnableAutoBlink() {
 
 // Disable Auto Blink
 func (m *Model) DisableAutoBlink() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	m.blinkManager = nil
 }
 
 // Update the model
+// Only the drawables whose dynamic flags changed are copied, and the buffers are reused, so it does not allocate
+// The callbacks of the motions are called at the end, after the model is unlocked
 func (m *Model) Update(delta float64) {
-	if m.motionManager != nil {
-		m.motionManager.Update(delta)
+	runPending(m.update(delta))
+}
+
+func (m *Model) update(delta float64) (pending []func()) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	defer func() {
+		pending = m.takePending()
+	}()
+	if m.closed {
+		return
 	}
+	delta *= m.timeScale
+	m.updateMotionLayers(delta)
 	if m.blinkManager != nil {
 		m.blinkManager.Update(delta)
 	}
 	m.core.Update(m.moc.ModelPtr)
+	for _, r := range m.recorders {
+		r.update(delta)
+	}
 
-	// Get the updated dynamic flags
-	dfs := m.core.GetDynamicFlags(m.moc.ModelPtr)
-
-	// Check the flags to confirm if there are any targets that need to be updated.
-	drawOrderDidChange := false
-	renderOrderDidChange := false
-	opacityDidChange := false
-	vertexPositionsDidChange := false
-	// blendColorDidChange := false
+	// Read the packed dynamic flags and the data of the core without copying
+	flags := core.GetDynamicFlagBits(m.core, m.moc.ModelPtr)
+	opacities := m.core.GetOpacities(m.moc.ModelPtr)
+	vertexPositions := m.core.GetVertexPositions(m.moc.ModelPtr)
+	orderDidChange := false
 	for i := range m.drawables {
-		m.drawables[i].DynamicFlag = dfs[i]
-		if dfs[i].DrawOrderDidChange {
-			drawOrderDidChange = true
+		d := &m.drawables[i]
+		d.DynamicFlag = drawable.ParseDynamicFlag(flags[i])
+		if d.DynamicFlag.DrawOrderDidChange || d.DynamicFlag.RenderOrderDidChange {
+			orderDidChange = true
 		}
-		if dfs[i].RenderOrderDidChange {
-			renderOrderDidChange = true
+		if d.DynamicFlag.OpacityDidChange {
+			d.Opacity = opacities[i]
 		}
-		if dfs[i].OpacityDidChange {
-			opacityDidChange = true
+		if d.DynamicFlag.VertexPositionsDidChange {
+			// Copy into the buffers of the model, as the core overwrites its memory on the next update
+			copy(d.VertexPositions, vertexPositions[i])
 		}
-		if dfs[i].VertexPositionsDidChange {
-			vertexPositionsDidChange = true
-		}
-		/*
-			if dfs[i].BlendColorDidChange {
-				blendColorDidChange = true
-			}
-		*/
+		// Update the multiplication color and screen color
+		// TODO impl
 	}
 
 	// Update the drawing order
-	if drawOrderDidChange || renderOrderDidChange {
-		m.sortedIndices = m.core.GetSortedDrawableIndices(m.moc.ModelPtr)
-	}
-	// Update the opacities
-	var opacities []float32
-	if opacityDidChange {
-		opacities = m.core.GetOpacities(m.moc.ModelPtr)
-	}
-	// Update the vertex positions
-	var vertexPositions [][]drawable.Vector2
-	if vertexPositionsDidChange {
-		vertexPositions = m.core.GetVertexPositions(m.moc.ModelPtr)
-	}
-	// Update the multiplication color and screen color
-	// TODO impl
-
-	for i := range m.drawables {
-		if opacityDidChange {
-			m.drawables[i].Opacity = opacities[i]
-		}
-		if vertexPositionsDidChange {
-			m.drawables[i].VertexPositions = vertexPositions[i]
+	if orderDidChange {
+		for i, order := range core.GetRenderOrders(m.core, m.moc.ModelPtr) {
+			m.sortedIndices[order] = i
 		}
 	}
+	m.frame++
+	return
 }
@@ -0,0 +1,380 @@
+package cubism_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/aethiopicuschan/cubism-go"
+	"github.com/aethiopicuschan/cubism-go/core/parameter"
+	"github.com/aethiopicuschan/cubism-go/motion"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetParameters(t *testing.T) {
+	t.Parallel()
+	m := loadFixture(t)
+
+	ps := m.GetParameters()
+	require.Len(t, ps, 6)
+	assert.Equal(t, "ParamAngleX", ps[0].Id)
+	assert.Equal(t, "Angle X", ps[0].Name)
+	assert.Equal(t, "ParamGroupFace", ps[0].GroupId)
+	assert.Equal(t, "Face", ps[0].GroupName)
+	assert.Equal(t, []float32{-30, 0, 30}, ps[0].KeyValues)
+	assert.True(t, ps[4].Repeat)
+	assert.Equal(t, parameter.BlendShape, ps[5].Type)
+
+	m.SetParameterValue("ParamAngleX", 15)
+	assert.Equal(t, float32(15), m.GetParameterValue("ParamAngleX"))
+}
+
+func TestGetParts(t *testing.T) {
+	t.Parallel()
+	m := loadFixture(t)
+
+	parts := m.GetParts()
+	require.Len(t, parts, 5)
+	assert.Equal(t, "Root", parts[0].Name)
+	assert.Equal(t, -1, parts[0].Parent)
+	assert.Equal(t, []int{1, 2}, parts[0].Children)
+	assert.Equal(t, []int{1, 2, 3}, parts[2].Drawables)
+	assert.Equal(t, float32(0), parts[4].Opacity)
+
+	m.SetPartOpacity("PartArmB", 1)
+	assert.Equal(t, float32(1), m.GetPartOpacity("PartArmB"))
+	p, err := m.GetPart("PartArmB")
+	require.NoError(t, err)
+	assert.Equal(t, float32(1), p.Opacity)
+	_, err = m.GetPart("PartUnknown")
+	assert.ErrorIs(t, err, cubism.ErrPartNotFound)
+}
+
+func TestUpdate(t *testing.T) {
+	t.Parallel()
+	m := loadFixture(t)
+
+	m.Update(0)
+	before := m.GetDrawables()[0].VertexPositions[0]
+	m.SetParameterValue("ParamAngleX", 30)
+	m.Update(0)
+	d := m.GetDrawables()[0]
+	assert.True(t, d.DynamicFlag.VertexPositionsDidChange)
+	assert.Greater(t, d.VertexPositions[0].X, before.X)
+}
+
+// Not parallel because of testing.AllocsPerRun
+func TestCopyDrawables(t *testing.T) {
+	m := loadFixture(t)
+
+	m.SetParameterValue("ParamAngleX", 10)
+	m.Update(0)
+	ds := m.CopyDrawables(nil)
+	require.Len(t, ds, 6)
+	assert.Equal(t, m.GetDrawables(), ds)
+	assert.Equal(t, m.UnsafeVertexPositions()[0], ds[0].VertexPositions)
+	copied := ds[0].VertexPositions[0]
+
+	// The copies are not affected by the next update and reusing them does not allocate
+	m.SetParameterValue("ParamAngleX", -10)
+	m.Update(0)
+	assert.Equal(t, copied, ds[0].VertexPositions[0])
+	allocs := testing.AllocsPerRun(10, func() {
+		ds = m.CopyDrawables(ds)
+	})
+	assert.Zero(t, allocs)
+	assert.Less(t, ds[0].VertexPositions[0].X, copied.X)
+
+	// The drawables are owned by Go and stay readable after the model is closed
+	view := m.GetDrawables()
+	require.NoError(t, m.Close())
+	assert.Equal(t, ds[0].VertexPositions, view[0].VertexPositions)
+	assert.Nil(t, m.UnsafeVertexPositions())
+	assert.Nil(t, m.UnsafeOpacities())
+}
+
+func TestPlayMotion(t *testing.T) {
+	t.Parallel()
+	m := loadFixture(t)
+
+	id, err := m.PlayMotion("Idle", 0, true)
+	require.NoError(t, err)
+	for i := 0; i < 30; i++ {
+		m.Update(1.0 / 30)
+	}
+	assert.Greater(t, m.GetParameterValue("ParamAngleX"), float32(0))
+
+	m.StopMotion(id)
+	m.SetParameterValue("ParamAngleX", 0)
+	m.Update(1.0 / 30)
+	assert.Equal(t, float32(0), m.GetParameterValue("ParamAngleX"))
+}
+
+func TestMotionControls(t *testing.T) {
+	t.Parallel()
+	m := loadFixture(t)
+
+	id, err := m.PlayMotion("Idle", 0, true)
+	require.NoError(t, err)
+	m.Update(0.5)
+
+	require.NoError(t, m.PauseMotion(id))
+	assert.True(t, m.IsMotionPaused(id))
+	value := m.GetParameterValue("ParamAngleX")
+	m.Update(0.5)
+	assert.Equal(t, value, m.GetParameterValue("ParamAngleX"))
+
+	require.NoError(t, m.SeekMotion(id, 1.5))
+	m.Update(0)
+	current, err := m.GetMotionTime(id)
+	require.NoError(t, err)
+	assert.Equal(t, 1.5, current)
+
+	require.NoError(t, m.ResumeMotion(id))
+	require.NoError(t, m.SetMotionSpeed(id, -2))
+	m.SetTimeScale(0.5)
+	m.Update(0.5)
+	current, err = m.GetMotionTime(id)
+	require.NoError(t, err)
+	assert.InDelta(t, 1, current, 1e-9)
+	require.NoError(t, m.SetMotionWeight(id, 0))
+
+	_, err = m.GetMotionTime(id + 1)
+	assert.ErrorIs(t, err, cubism.ErrMotionNotFound)
+	assert.ErrorIs(t, m.PauseMotion(id+1), cubism.ErrMotionNotFound)
+	m.StopMotion(id)
+	assert.ErrorIs(t, m.SeekMotion(id, 0), cubism.ErrMotionNotFound)
+}
+
+func TestOnMotionEvent(t *testing.T) {
+	t.Parallel()
+	m := loadFixture(t)
+
+	events := []cubism.MotionEvent{}
+	m.OnMotionEvent(func(e cubism.MotionEvent) {
+		// The model is unlocked while dispatching
+		m.GetParameterValue("ParamAngleX")
+		events = append(events, e)
+	})
+	id, err := m.PlayMotion("Idle", 0, true)
+	require.NoError(t, err)
+	for i := 0; i < 45; i++ {
+		m.Update(0.1)
+	}
+	assert.Equal(t, []cubism.MotionEvent{
+		{MotionId: id, Time: 1, Value: "peak"},
+		{MotionId: id, Time: 1, Value: "peak"},
+	}, events)
+}
+
+func TestMotionCallbacks(t *testing.T) {
+	t.Parallel()
+	m := loadFixture(t)
+
+	calls := []string{}
+	record := func(kind string) func(int) {
+		return func(id int) {
+			calls = append(calls, fmt.Sprintf("%s %d", kind, id))
+		}
+	}
+	m.OnMotionStarted(record("started"))
+	m.OnMotionLooped(record("looped"))
+	m.OnMotionFinished(record("finished"))
+
+	once, err := m.PlayMotionWithOptions("Idle", 0, cubism.WithLoop(false))
+	require.NoError(t, err)
+	done := m.WaitMotion(once)
+	for i := 0; i < 25; i++ {
+		m.Update(0.1)
+	}
+	assert.Equal(t, []string{"started 1", "finished 1"}, calls)
+	select {
+	case <-done:
+	default:
+		t.Fatal("the channel is not closed after the motion finished")
+	}
+
+	calls = calls[:0]
+	loop, err := m.PlayMotion("Idle", 0, true)
+	require.NoError(t, err)
+	done = m.WaitMotion(loop)
+	for i := 0; i < 25; i++ {
+		m.Update(0.1)
+	}
+	select {
+	case <-done:
+		t.Fatal("the channel is closed while the motion loops")
+	default:
+	}
+	m.StopMotion(loop)
+	<-done
+	assert.Equal(t, []string{"started 2", "looped 2", "finished 2"}, calls)
+
+	// Already finished or unknown motions do not block
+	<-m.WaitMotion(loop)
+	<-m.WaitMotion(100)
+}
+
+func TestPlayMotionLoop(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		groupName string
+		loop      bool
+		loops     bool
+	}{
+		{name: "meta loop", groupName: "Idle", loops: true},
+		{name: "meta loop forced", groupName: "Idle", loop: true, loops: true},
+		{name: "without meta loop", groupName: "TapBody"},
+		{name: "without meta loop forced", groupName: "TapBody", loop: true, loops: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			m := loadFixture(t)
+			id, err := m.PlayMotion(tt.groupName, 0, tt.loop)
+			require.NoError(t, err)
+			for i := 0; i < 25; i++ {
+				m.Update(0.1)
+			}
+			_, err = m.GetMotionTime(id)
+			if tt.loops {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, cubism.ErrMotionNotFound)
+			}
+		})
+	}
+}
+
+func TestPlayMotionWithOptions(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		opts    []func(*cubism.PlayOption)
+		wantErr error
+		loops   bool
+	}{
+		{name: "meta loop", loops: true},
+		{name: "without loop", opts: []func(*cubism.PlayOption){cubism.WithLoop(false)}},
+		{name: "fade in on loop", opts: []func(*cubism.PlayOption){cubism.WithFadeInOnLoop()}, loops: true},
+		{name: "unknown layer", opts: []func(*cubism.PlayOption){cubism.WithLayer("face")}, wantErr: cubism.ErrMotionLayerNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			m := loadFixture(t)
+			id, err := m.PlayMotionWithOptions("Idle", 0, tt.opts...)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			for i := 0; i < 25; i++ {
+				m.Update(0.1)
+			}
+			_, err = m.GetMotionTime(id)
+			if tt.loops {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, cubism.ErrMotionNotFound)
+			}
+		})
+	}
+}
+
+func TestPlayMotionData(t *testing.T) {
+	t.Parallel()
+	m := loadFixture(t)
+
+	mtn, err := motion.NewBuilder(1).
+		Curve(motion.NewCurveBuilder("Parameter", "ParamAngleX", motion.Point{Time: 0, Value: 0}).
+			Linear(motion.Point{Time: 1, Value: 10})).
+		Build()
+	require.NoError(t, err)
+	// The sound is not loaded, so it is not played
+	mtn.Sound = "voice.wav"
+
+	_, err = m.PlayMotionData(mtn, cubism.WithLayer("face"))
+	assert.ErrorIs(t, err, cubism.ErrMotionLayerNotFound)
+	id, err := m.PlayMotionData(mtn, cubism.WithLoop(false))
+	require.NoError(t, err)
+	m.Update(0.5)
+	assert.InDelta(t, 5, m.GetParameterValue("ParamAngleX"), 1e-4)
+
+	// It cannot be restored, so it is not saved
+	s, err := m.SaveState()
+	require.NoError(t, err)
+	assert.Empty(t, s.MotionLayers[0].Motions)
+
+	m.Update(0.6)
+	_, err = m.GetMotionTime(id)
+	assert.ErrorIs(t, err, cubism.ErrMotionNotFound)
+}
+
+func TestWaitMotionClose(t *testing.T) {
+	t.Parallel()
+	m := loadFixture(t)
+
+	id, err := m.PlayMotion("Idle", 0, true)
+	require.NoError(t, err)
+	done := m.WaitMotion(id)
+	go func() {
+		m.Update(0.1)
+		assert.NoError(t, m.Close())
+	}()
+	<-done
+}
+
+func TestAutoBlink(t *testing.T) {
+	t.Parallel()
+	m := loadFixture(t)
+
+	m.EnableAutoBlink()
+	closed := false
+	for i := 0; i < 60*10; i++ {
+		m.Update(1.0 / 60)
+		if m.GetParameterValue("ParamEyeLOpen") < 1 {
+			closed = true
+		}
+	}
+	assert.True(t, closed)
+}
+
+func TestWriteMotion(t *testing.T) {
+	t.Parallel()
+
+	mtn, err := motion.NewBuilder(1).
+		Curve(motion.NewCurveBuilder("Parameter", "ParamAngleX", motion.Point{Time: 0, Value: 0}).
+			Linear(motion.Point{Time: 0.5, Value: 10}).
+			Bezier(motion.Point{Time: 0.6, Value: 10}, motion.Point{Time: 0.9, Value: 0}, motion.Point{Time: 1, Value: 0})).
+		UserData(0.5, "peak").
+		Build()
+	require.NoError(t, err)
+	var b bytes.Buffer
+	require.NoError(t, cubism.WriteMotion(&b, mtn))
+
+	var written struct {
+		Version int
+		Meta    map[string]any
+	}
+	require.NoError(t, json.Unmarshal(b.Bytes(), &written))
+	assert.Equal(t, 3, written.Version)
+	assert.Equal(t, map[string]any{
+		"Duration":             1.0,
+		"Fps":                  30.0,
+		"Loop":                 false,
+		"AreBeziersRestricted": false,
+		"CurveCount":           1.0,
+		"TotalSegmentCount":    2.0,
+		"TotalPointCount":      5.0,
+		"UserDataCount":        1.0,
+		"TotalUserDataSize":    4.0,
+	}, written.Meta)
+}
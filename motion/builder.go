@@ -0,0 +1,184 @@
+package motion
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Builds a curve from its start point, appending the segments in order
+type CurveBuilder struct {
+	curve Curve
+	last  Point
+}
+
+// Start a curve of the target, which is "Parameter", "PartOpacity" or "Model", at the start point
+// The curve uses the fade of the motion unless [CurveBuilder.FadeIn] or [CurveBuilder.FadeOut] is called
+func NewCurveBuilder(target, id string, start Point) *CurveBuilder {
+	return &CurveBuilder{
+		curve: Curve{
+			Target:      target,
+			Id:          id,
+			FadeInTime:  -1,
+			FadeOutTime: -1,
+		},
+		last: start,
+	}
+}
+
+// Set the fade in time of the curve in seconds
+func (b *CurveBuilder) FadeIn(t float64) *CurveBuilder {
+	b.curve.FadeInTime = t
+	return b
+}
+
+// Set the fade out time of the curve in seconds
+func (b *CurveBuilder) FadeOut(t float64) *CurveBuilder {
+	b.curve.FadeOutTime = t
+	return b
+}
+
+// Append a segment interpolating linearly to the end point
+func (b *CurveBuilder) Linear(end Point) *CurveBuilder {
+	return b.append(Segment{
+		Points: []Point{b.last, end},
+		Type:   Linear,
+	}, end)
+}
+
+// Append a cubic Bezier segment with the two control points to the end point
+func (b *CurveBuilder) Bezier(control1, control2, end Point) *CurveBuilder {
+	return b.append(Segment{
+		Points: []Point{b.last, control1, control2, end},
+		Type:   Bezier,
+	}, end)
+}
+
+// Append a segment holding the current value until the end point
+func (b *CurveBuilder) Stepped(end Point) *CurveBuilder {
+	return b.append(Segment{
+		Points: []Point{b.last, end},
+		Type:   Stepped,
+		Value:  end.Time,
+	}, end)
+}
+
+// Append a segment jumping to the value of the end point right away and holding it
+func (b *CurveBuilder) InverseStepped(end Point) *CurveBuilder {
+	return b.append(Segment{
+		Points: []Point{end, b.last},
+		Type:   InverseStepped,
+		Value:  b.last.Time,
+	}, end)
+}
+
+func (b *CurveBuilder) append(seg Segment, end Point) *CurveBuilder {
+	b.curve.Segments = append(b.curve.Segments, seg)
+	b.last = end
+	return b
+}
+
+// Get the curve
+func (b *CurveBuilder) Build() (c Curve) {
+	c = b.curve
+	c.Segments = append([]Segment(nil), b.curve.Segments...)
+	return
+}
+
+// Builds a motion
+type Builder struct {
+	motion   Motion
+	curves   []*CurveBuilder
+	userData []UserData
+}
+
+// Start a motion of the duration in seconds at 30 fps
+func NewBuilder(duration float64) *Builder {
+	return &Builder{
+		motion: Motion{
+			Meta: Meta{
+				Duration: duration,
+				Fps:      30,
+			},
+		},
+	}
+}
+
+// Set whether the motion loops
+func (b *Builder) Loop(loop bool) *Builder {
+	b.motion.Meta.Loop = loop
+	return b
+}
+
+// Set the frame rate of the motion
+func (b *Builder) Fps(fps float64) *Builder {
+	b.motion.Meta.Fps = fps
+	return b
+}
+
+// Set the fade times of the motion in seconds
+// They are not saved to motion3.json, as they belong to model3.json
+func (b *Builder) Fade(fadeIn, fadeOut float64) *Builder {
+	b.motion.FadeInTime = fadeIn
+	b.motion.FadeOutTime = fadeOut
+	return b
+}
+
+// Add a curve, which is built by [Builder.Build]
+func (b *Builder) Curve(curve *CurveBuilder) *Builder {
+	b.curves = append(b.curves, curve)
+	return b
+}
+
+// Add an event fired when the playback reaches the time
+func (b *Builder) UserData(t float64, value string) *Builder {
+	b.userData = append(b.userData, UserData{Time: t, Value: value})
+	return b
+}
+
+// Get the motion
+// It returns an error if a curve has no segments or goes backwards in time, or if the curves or the events are out of the duration
+func (b *Builder) Build() (mtn Motion, err error) {
+	mtn = b.motion
+	mtn.Curves = nil
+	for _, cb := range b.curves {
+		c := cb.Build()
+		if err = checkCurve(c, mtn.Meta.Duration); err != nil {
+			return
+		}
+		mtn.Curves = append(mtn.Curves, c)
+	}
+	mtn.UserData = append([]UserData(nil), b.userData...)
+	sort.SliceStable(mtn.UserData, func(i, j int) bool {
+		return mtn.UserData[i].Time < mtn.UserData[j].Time
+	})
+	for _, u := range mtn.UserData {
+		if u.Time < 0 || u.Time > mtn.Meta.Duration {
+			err = fmt.Errorf("user data %q at %g is out of the duration %g", u.Value, u.Time, mtn.Meta.Duration)
+			return
+		}
+	}
+	return
+}
+
+func checkCurve(c Curve, duration float64) error {
+	if len(c.Segments) == 0 {
+		return fmt.Errorf("curve %s has no segments", c.Id)
+	}
+	last := 0.0
+	for i, seg := range c.Segments {
+		for _, p := range seg.Points {
+			if p.Time < 0 || p.Time > duration {
+				return fmt.Errorf("segment %d of %s is out of the duration %g", i, c.Id, duration)
+			}
+		}
+		start, end := seg.Points[0].Time, seg.Points[len(seg.Points)-1].Time
+		if seg.Type == InverseStepped {
+			start, end = end, start
+		}
+		if start < last || end < start {
+			return fmt.Errorf("segment %d of %s goes backwards in time", i, c.Id)
+		}
+		last = end
+	}
+	return nil
+}
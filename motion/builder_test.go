@@ -0,0 +1,83 @@
+package motion_test
+
+import (
+	"testing"
+
+	"github.com/aethiopicuschan/cubism-go/motion"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuilder(t *testing.T) {
+	t.Parallel()
+
+	curve := motion.NewCurveBuilder("Parameter", "ParamAngleX", motion.Point{Time: 0, Value: 0}).
+		FadeIn(0.5).
+		Linear(motion.Point{Time: 1, Value: 10}).
+		Bezier(motion.Point{Time: 1.3, Value: 10}, motion.Point{Time: 1.7, Value: 0}, motion.Point{Time: 2, Value: 0}).
+		Stepped(motion.Point{Time: 2.5, Value: 5}).
+		InverseStepped(motion.Point{Time: 3, Value: -5})
+	mtn, err := motion.NewBuilder(3).
+		Loop(true).
+		Fade(1, 1).
+		Curve(curve).
+		UserData(2, "second").
+		UserData(1, "first").
+		Build()
+	require.NoError(t, err)
+
+	assert.Equal(t, motion.Meta{Duration: 3, Fps: 30, Loop: true}, mtn.Meta)
+	assert.Equal(t, 1.0, mtn.FadeInTime)
+	assert.Equal(t, []motion.UserData{{Time: 1, Value: "first"}, {Time: 2, Value: "second"}}, mtn.UserData)
+	require.Len(t, mtn.Curves, 1)
+	c := mtn.Curves[0]
+	assert.Equal(t, 0.5, c.FadeInTime)
+	assert.Equal(t, -1.0, c.FadeOutTime)
+	assert.Equal(t, []motion.Segment{
+		{Type: motion.Linear, Points: []motion.Point{{Time: 0, Value: 0}, {Time: 1, Value: 10}}},
+		{Type: motion.Bezier, Points: []motion.Point{{Time: 1, Value: 10}, {Time: 1.3, Value: 10}, {Time: 1.7, Value: 0}, {Time: 2, Value: 0}}},
+		{Type: motion.Stepped, Points: []motion.Point{{Time: 2, Value: 0}, {Time: 2.5, Value: 5}}, Value: 2.5},
+		{Type: motion.InverseStepped, Points: []motion.Point{{Time: 3, Value: -5}, {Time: 2.5, Value: 5}}, Value: 2.5},
+	}, c.Segments)
+
+	// Building again does not share the segments
+	again, err := motion.NewBuilder(3).Curve(curve).Build()
+	require.NoError(t, err)
+	again.Curves[0].Segments[0].Type = motion.Stepped
+	assert.Equal(t, motion.Linear, mtn.Curves[0].Segments[0].Type)
+}
+
+func TestBuilderErrors(t *testing.T) {
+	t.Parallel()
+
+	start := motion.Point{Time: 0, Value: 0}
+	tests := []struct {
+		name    string
+		builder *motion.Builder
+	}{
+		{
+			name:    "no segments",
+			builder: motion.NewBuilder(1).Curve(motion.NewCurveBuilder("Parameter", "ParamAngleX", start)),
+		},
+		{
+			name:    "backwards",
+			builder: motion.NewBuilder(1).Curve(motion.NewCurveBuilder("Parameter", "ParamAngleX", motion.Point{Time: 0.5}).Linear(start)),
+		},
+		{
+			name:    "beyond the duration",
+			builder: motion.NewBuilder(1).Curve(motion.NewCurveBuilder("Parameter", "ParamAngleX", start).Linear(motion.Point{Time: 2})),
+		},
+		{
+			name:    "user data beyond the duration",
+			builder: motion.NewBuilder(1).UserData(2, "late"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			_, err := tt.builder.Build()
+			assert.Error(t, err)
+		})
+	}
+}
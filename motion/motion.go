@@ -11,6 +11,7 @@ const (
 
 type Meta struct {
 	Duration             float64
+	Fps                  float64
 	Loop                 bool
 	AreBeziersRestricted bool
 }
@@ -21,9 +22,14 @@ type Point struct {
 }
 
 type Segment struct {
+	// Linear: the start and the end
+	// Bezier: the start, the two control points and the end
+	// Stepped: the start, whose value is held until the end, and the end
+	// InverseStepped: the end, whose value is held from the start, and the start
 	Points []Point
 	Type   int
-	Value  float64
+	// The end time for Stepped and the start time for InverseStepped
+	Value float64
 }
 
 type Curve struct {
@@ -34,6 +40,12 @@ type Curve struct {
 	Segments    []Segment
 }
 
+// An event of motion3.json fired when the playback reaches its time
+type UserData struct {
+	Time  float64
+	Value string
+}
+
 type Motion struct {
 	File        string
 	FadeInTime  float64
@@ -42,4 +54,6 @@ type Motion struct {
 	LoadedSound sound.Sound
 	Meta        Meta
 	Curves      []Curve
+	// Sorted by time
+	UserData []UserData
 }
@@ -0,0 +1,125 @@
+package cubism
+
+// An event of the UserData of motion3.json
+// It is fired each time the playback crosses its time, including after looping and seeking
+type MotionEvent struct {
+	// The ID returned by [Model.PlayMotion]
+	MotionId int
+	// Time of the event in seconds
+	Time  float64
+	Value string
+}
+
+// The callbacks below are called after the model is unlocked, so they may call the methods of the model
+// Passing nil removes the function
+
+// Set the function called when a playing motion fires an event
+// It is called from [Model.Update]
+func (m *Model) OnMotionEvent(f func(MotionEvent)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.onMotionEvent = f
+}
+
+// Set the function called when a motion is started by [Model.PlayMotion]
+func (m *Model) OnMotionStarted(f func(id int)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.onMotionStarted = f
+}
+
+// Set the function called when a motion reaches its end without looping or is stopped by [Model.StopMotion]
+func (m *Model) OnMotionFinished(f func(id int)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.onMotionFinished = f
+}
+
+// Set the function called when a looping motion starts over
+// It is called at most once per [Model.Update], even if the motion wrapped several times
+func (m *Model) OnMotionLooped(f func(id int)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.onMotionLooped = f
+}
+
+// Get a channel which is closed when the motion finishes, is stopped or the model is closed
+// If the motion is not playing, the channel is already closed
+// A looping motion only finishes when it is stopped
+func (m *Model) WaitMotion(id int) <-chan struct{} {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	ch := make(chan struct{})
+	if m.closed || m.findMotion(id) == nil {
+		close(ch)
+		return ch
+	}
+	if m.motionWaiters == nil {
+		m.motionWaiters = make(map[int][]chan struct{})
+	}
+	m.motionWaiters[id] = append(m.motionWaiters[id], ch)
+	return ch
+}
+
+// Queue a callback to be called once the model is unlocked
+// The model must be locked
+func (m *Model) notify(f func()) {
+	m.pending = append(m.pending, f)
+}
+
+// Take the queued callbacks
+// The model must be locked
+func (m *Model) takePending() (pending []func()) {
+	pending, m.pending = m.pending, nil
+	return
+}
+
+func runPending(pending []func()) {
+	for _, f := range pending {
+		f()
+	}
+}
+
+// Handle the end of a motion
+// The model must be locked
+func (m *Model) finishMotion(id int) {
+	m.forgetMotion(id)
+	for _, ch := range m.motionWaiters[id] {
+		close(ch)
+	}
+	delete(m.motionWaiters, id)
+	if f := m.onMotionFinished; f != nil {
+		m.notify(func() { f(id) })
+	}
+}
+
+// Forget the group and index of a motion which is no longer played
+// The model must be locked
+func (m *Model) forgetMotion(id int) {
+	if _, ok := m.playingMotions[id]; ok {
+		delete(m.playingMotions, id)
+		m.motions.settle()
+	}
+}
+
+// Check whether a layer is playing the motion
+// The model must be locked
+func (m *Model) isMotionPlaying(key motionKey) bool {
+	for _, k := range m.playingMotions {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+// Release all the waiters, when the model is closed
+// The model must be locked
+func (m *Model) releaseMotionWaiters() {
+	for _, chs := range m.motionWaiters {
+		for _, ch := range chs {
+			close(ch)
+		}
+	}
+	m.motionWaiters = nil
+}
@@ -0,0 +1,237 @@
+package cubism
+
+import (
+	"errors"
+	"fmt"
+
+	internalmotion "github.com/aethiopicuschan/cubism-go/internal/motion"
+	"github.com/aethiopicuschan/cubism-go/motion"
+)
+
+// How the motions of a layer are combined with the layers below
+type MotionBlend int
+
+const (
+	// Interpolate from the current values to the values of the motion by the weight
+	MotionBlendOverride MotionBlend = iota
+	// Add the difference between the values of the motion and the default values, scaled by the weight
+	// The part opacities are overridden even in this mode
+	MotionBlendAdditive
+)
+
+// Name of the layer which always exists and is used by [Model.PlayMotion]
+const BaseMotionLayer = "base"
+
+// A layer playing its own motions
+type motionLayer struct {
+	name    string
+	manager *internalmotion.MotionManager
+}
+
+func (m *Model) newMotionLayer(name string, blend MotionBlend) *motionLayer {
+	var mm *internalmotion.MotionManager
+	mm = internalmotion.NewMotionManager(m.core, m.moc.ModelPtr, func(id int) {
+		mm.Close(id)
+		m.finishMotion(id)
+	})
+	mm.SetLoopHandler(func(id int) {
+		if f := m.onMotionLooped; f != nil {
+			m.notify(func() { f(id) })
+		}
+	})
+	mm.SetEventHandler(func(id int, u motion.UserData) {
+		if f := m.onMotionEvent; f != nil {
+			e := MotionEvent{MotionId: id, Time: u.Time, Value: u.Value}
+			m.notify(func() { f(e) })
+		}
+	})
+	if blend == MotionBlendAdditive {
+		mm.SetBlend(internalmotion.BlendAdditive)
+	}
+	return &motionLayer{
+		name:    name,
+		manager: mm,
+	}
+}
+
+// The model must be locked
+func (m *Model) findMotionLayer(name string) (l *motionLayer, err error) {
+	for _, l := range m.motionLayers {
+		if l.name == name {
+			return l, nil
+		}
+	}
+	err = fmt.Errorf("%w: %s", ErrMotionLayerNotFound, name)
+	return
+}
+
+// Get the manager playing the motion, or nil
+// The model must be locked
+func (m *Model) findMotion(id int) *internalmotion.MotionManager {
+	for _, l := range m.motionLayers {
+		if l.manager.IsPlaying(id) {
+			return l.manager
+		}
+	}
+	return nil
+}
+
+// Stop the motion played by mm
+// The model must be locked
+func (m *Model) closeMotion(mm *internalmotion.MotionManager, id int) {
+	mm.Close(id)
+	m.finishMotion(id)
+	m.deactivateMotions()
+}
+
+// Check whether a layer applies a motion on the next update
+// The model must be locked
+func (m *Model) hasActiveMotions() bool {
+	for _, l := range m.motionLayers {
+		if l.manager.IsActive() {
+			return true
+		}
+	}
+	return false
+}
+
+// Remove the contribution of the additive layers once the last motion is stopped
+// The model must be locked
+func (m *Model) deactivateMotions() {
+	if !m.motionsActive || m.hasActiveMotions() {
+		return
+	}
+	m.parameterStore.Load()
+	m.motionsActive = false
+}
+
+// Add a motion layer on top of the others
+// The layers with [MotionBlendOverride] are applied first and those with [MotionBlendAdditive] afterwards,
+// each in the order they were added, and the base layer is always the first one
+func (m *Model) AddMotionLayer(name string, blend MotionBlend) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.closed {
+		return ErrClosed
+	}
+	if _, err := m.findMotionLayer(name); err == nil {
+		return fmt.Errorf("%w: %s", ErrMotionLayerExists, name)
+	}
+	m.motionLayers = append(m.motionLayers, m.newMotionLayer(name, blend))
+	return nil
+}
+
+// Remove a motion layer and stop its motions
+// The base layer cannot be removed
+func (m *Model) RemoveMotionLayer(name string) error {
+	pending, err := m.removeMotionLayer(name)
+	runPending(pending)
+	return err
+}
+
+func (m *Model) removeMotionLayer(name string) (pending []func(), err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.closed {
+		err = ErrClosed
+		return
+	}
+	if name == BaseMotionLayer {
+		err = errors.New("cannot remove the base motion layer")
+		return
+	}
+	for i, l := range m.motionLayers {
+		if l.name != name {
+			continue
+		}
+		for _, id := range l.manager.GetIds() {
+			m.closeMotion(l.manager, id)
+		}
+		m.motionLayers = append(m.motionLayers[:i], m.motionLayers[i+1:]...)
+		pending = m.takePending()
+		return
+	}
+	err = fmt.Errorf("%w: %s", ErrMotionLayerNotFound, name)
+	return
+}
+
+// Get the names of the motion layers in the order of composition
+func (m *Model) GetMotionLayerNames() (names []string) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for _, l := range m.orderedMotionLayers() {
+		names = append(names, l.name)
+	}
+	return
+}
+
+// Set the weight of a motion layer
+// The weight is clamped to [0, 1] and multiplied by the weights of its motions
+func (m *Model) SetMotionLayerWeight(name string, weight float64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.closed {
+		return ErrClosed
+	}
+	l, err := m.findMotionLayer(name)
+	if err != nil {
+		return err
+	}
+	l.manager.SetManagerWeight(weight)
+	return nil
+}
+
+// Get the weight of a motion layer
+func (m *Model) GetMotionLayerWeight(name string) (weight float64, err error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.closed {
+		err = ErrClosed
+		return
+	}
+	l, err := m.findMotionLayer(name)
+	if err != nil {
+		return
+	}
+	weight = l.manager.GetManagerWeight()
+	return
+}
+
+// The layers in the order of composition
+// It allocates, so it is not used by Update
+func (m *Model) orderedMotionLayers() (layers []*motionLayer) {
+	for _, blend := range []internalmotion.Blend{internalmotion.BlendOverride, internalmotion.BlendAdditive} {
+		for _, l := range m.motionLayers {
+			if l.manager.GetBlend() == blend {
+				layers = append(layers, l)
+			}
+		}
+	}
+	return
+}
+
+// Apply the motion layers
+// The values of the override layers are kept for the next update, so that the additive layers do not accumulate
+// The model must be locked
+func (m *Model) updateMotionLayers(delta float64) {
+	if !m.hasActiveMotions() {
+		m.motionsActive = false
+		return
+	}
+	if m.motionsActive {
+		m.parameterStore.Load()
+	}
+	for _, l := range m.motionLayers {
+		if l.manager.GetBlend() == internalmotion.BlendOverride {
+			l.manager.Update(delta)
+		}
+	}
+	m.parameterStore.Save()
+	for _, l := range m.motionLayers {
+		if l.manager.GetBlend() == internalmotion.BlendAdditive {
+			l.manager.Update(delta)
+		}
+	}
+	// If the last motions finished, the values loaded above are already free of the additive layers
+	m.motionsActive = m.hasActiveMotions()
+}
@@ -0,0 +1,78 @@
+package cubism_test
+
+import (
+	"testing"
+
+	"github.com/aethiopicuschan/cubism-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMotionLayers(t *testing.T) {
+	t.Parallel()
+	m := loadFixture(t)
+
+	require.NoError(t, m.AddMotionLayer("face", cubism.MotionBlendAdditive))
+	require.NoError(t, m.AddMotionLayer("arms", cubism.MotionBlendOverride))
+	assert.ErrorIs(t, m.AddMotionLayer("face", cubism.MotionBlendOverride), cubism.ErrMotionLayerExists)
+	assert.Equal(t, []string{cubism.BaseMotionLayer, "arms", "face"}, m.GetMotionLayerNames())
+	_, err := m.PlayMotionOnLayer("unknown", "Idle", 0, false)
+	assert.ErrorIs(t, err, cubism.ErrMotionLayerNotFound)
+	assert.Error(t, m.RemoveMotionLayer(cubism.BaseMotionLayer))
+
+	require.NoError(t, m.RemoveMotionLayer("arms"))
+	assert.Equal(t, []string{cubism.BaseMotionLayer, "face"}, m.GetMotionLayerNames())
+	assert.ErrorIs(t, m.RemoveMotionLayer("arms"), cubism.ErrMotionLayerNotFound)
+}
+
+func TestMotionLayerAdditive(t *testing.T) {
+	t.Parallel()
+	m := loadFixture(t)
+	require.NoError(t, m.AddMotionLayer("face", cubism.MotionBlendAdditive))
+
+	m.SetParameterValue("ParamAngleX", 5)
+	id, err := m.PlayMotionOnLayer("face", "Idle", 0, true)
+	require.NoError(t, err)
+	for i := 0; i < 10; i++ {
+		m.Update(0.1)
+	}
+	assert.InDelta(t, 15, m.GetParameterValue("ParamAngleX"), 1.5)
+
+	// The additive values do not accumulate across the updates and the loops
+	for i := 0; i < 20; i++ {
+		m.Update(0.1)
+	}
+	assert.InDelta(t, 15, m.GetParameterValue("ParamAngleX"), 1.5)
+
+	require.NoError(t, m.SetMotionLayerWeight("face", 0.5))
+	weight, err := m.GetMotionLayerWeight("face")
+	require.NoError(t, err)
+	assert.Equal(t, 0.5, weight)
+	for i := 0; i < 20; i++ {
+		m.Update(0.1)
+	}
+	assert.InDelta(t, 10, m.GetParameterValue("ParamAngleX"), 1)
+
+	// Stopping the last motion removes its contribution
+	m.StopMotion(id)
+	assert.InDelta(t, 5, m.GetParameterValue("ParamAngleX"), 1e-5)
+	m.Update(0.1)
+	assert.InDelta(t, 5, m.GetParameterValue("ParamAngleX"), 1e-5)
+}
+
+func TestMotionLayerOverride(t *testing.T) {
+	t.Parallel()
+	m := loadFixture(t)
+	require.NoError(t, m.AddMotionLayer("upper", cubism.MotionBlendOverride))
+
+	base, err := m.PlayMotion("Idle", 0, true)
+	require.NoError(t, err)
+	upper, err := m.PlayMotionOnLayer("upper", "Idle", 0, true)
+	require.NoError(t, err)
+	assert.NotEqual(t, base, upper)
+	require.NoError(t, m.SetMotionLayerWeight("upper", 0))
+	require.NoError(t, m.SeekMotion(upper, 1))
+	m.Update(0)
+	// The upper layer has no weight, so the base layer at the beginning wins
+	assert.InDelta(t, 0, m.GetParameterValue("ParamAngleX"), 1e-5)
+}
@@ -0,0 +1,118 @@
+package cubism
+
+import (
+	"fmt"
+	"math/rand/v2"
+)
+
+// Play a motion of the group chosen at random
+// Use [WithoutRepeat] and [WithWeights] to control the choice
+func (m *Model) PlayRandomMotion(groupName string, opts ...func(*PlayOption)) (id int, err error) {
+	o := newPlayOption(opts)
+	id, pending, err := m.playMotion(groupName, func() (int, error) {
+		return m.randomMotionIndex(groupName, o)
+	}, o)
+	runPending(pending)
+	return
+}
+
+// Play the motion following the one played last in the group, going back to the first one after the last one
+// The first motion is played if none of the group has been played yet
+func (m *Model) PlayNextMotion(groupName string, opts ...func(*PlayOption)) (id int, err error) {
+	id, pending, err := m.playMotion(groupName, func() (int, error) {
+		return m.nextMotionIndex(groupName)
+	}, newPlayOption(opts))
+	runPending(pending)
+	return
+}
+
+// Get the number of motions in the group, or an error if it has none
+// The model must be locked
+func (m *Model) countMotions(groupName string) (count int, err error) {
+	refs, ok := m.motions.refs[groupName]
+	if !ok {
+		err = fmt.Errorf("%w: %s", ErrMotionGroupNotFound, groupName)
+		return
+	}
+	count = len(refs)
+	if count == 0 {
+		err = fmt.Errorf("%w: %s has no motions", ErrMotionIndexOutOfRange, groupName)
+	}
+	return
+}
+
+// The model must be locked
+func (m *Model) randomMotionIndex(groupName string, o *PlayOption) (index int, err error) {
+	count, err := m.countMotions(groupName)
+	if err != nil {
+		return
+	}
+	weights := o.weights
+	if weights == nil {
+		weights = make([]float64, count)
+		for i := range weights {
+			weights[i] = 1
+		}
+	}
+	if len(weights) != count {
+		err = fmt.Errorf("%d weights for %d motions of %s", len(weights), count, groupName)
+		return
+	}
+	last, played := m.lastMotionIndices[groupName]
+	excluded := -1
+	if o.noRepeat && played && count > 1 {
+		excluded = last
+	}
+	total := 0.0
+	for i, w := range weights {
+		if w < 0 {
+			err = fmt.Errorf("negative weight %g for motion %d of %s", w, i, groupName)
+			return
+		}
+		if i != excluded {
+			total += w
+		}
+	}
+	if total == 0 {
+		// Repeating is better than playing nothing
+		if excluded == -1 || weights[excluded] == 0 {
+			err = fmt.Errorf("all the weights of %s are zero", groupName)
+			return
+		}
+		index = excluded
+		return
+	}
+	r := rand.Float64() * total
+	for i, w := range weights {
+		if i == excluded || w == 0 {
+			continue
+		}
+		index = i
+		if r < w {
+			return
+		}
+		r -= w
+	}
+	return
+}
+
+// The model must be locked
+func (m *Model) nextMotionIndex(groupName string) (index int, err error) {
+	count, err := m.countMotions(groupName)
+	if err != nil {
+		return
+	}
+	if last, ok := m.lastMotionIndices[groupName]; ok {
+		index = (last + 1) % count
+	}
+	return
+}
+
+// Get the index of the motion played last in the group
+// It tells which motion [Model.PlayRandomMotion] and [Model.PlayNextMotion] chose
+func (m *Model) GetLastMotionIndex(groupName string) (index int, ok bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	index, ok = m.lastMotionIndices[groupName]
+	return
+}
@@ -0,0 +1,140 @@
+package cubism_test
+
+import (
+	"testing"
+
+	"github.com/aethiopicuschan/cubism-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Play the motions n times and record the indices chosen in the group
+func playIndices(t *testing.T, m *cubism.Model, groupName string, n int, play func() (int, error)) (indices []int) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		_, err := play()
+		require.NoError(t, err)
+		index, ok := m.GetLastMotionIndex(groupName)
+		require.True(t, ok)
+		indices = append(indices, index)
+	}
+	return
+}
+
+func TestPlayNextMotion(t *testing.T) {
+	t.Parallel()
+	m := loadFixture(t)
+
+	_, ok := m.GetLastMotionIndex("Greeting")
+	assert.False(t, ok)
+	indices := playIndices(t, m, "Greeting", 2, func() (int, error) {
+		return m.PlayNextMotion("Greeting")
+	})
+	assert.Equal(t, []int{0, 1}, indices)
+
+	// The motions played by index are followed too
+	_, err := m.PlayMotion("Greeting", 0, false)
+	require.NoError(t, err)
+	indices = playIndices(t, m, "Greeting", 4, func() (int, error) {
+		return m.PlayNextMotion("Greeting", cubism.WithLoop(false))
+	})
+	assert.Equal(t, []int{1, 2, 0, 1}, indices)
+}
+
+func TestPlayRandomMotion(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		opts  []func(*cubism.PlayOption)
+		check func(t *testing.T, indices []int)
+	}{
+		{
+			name: "uniform",
+			check: func(t *testing.T, indices []int) {
+				assert.Subset(t, indices, []int{0, 1, 2})
+			},
+		},
+		{
+			name: "without repeat",
+			opts: []func(*cubism.PlayOption){cubism.WithoutRepeat()},
+			check: func(t *testing.T, indices []int) {
+				for i := 1; i < len(indices); i++ {
+					assert.NotEqual(t, indices[i-1], indices[i])
+				}
+			},
+		},
+		{
+			name: "weights",
+			opts: []func(*cubism.PlayOption){cubism.WithWeights(0, 1, 0)},
+			check: func(t *testing.T, indices []int) {
+				for _, index := range indices {
+					assert.Equal(t, 1, index)
+				}
+			},
+		},
+		{
+			name: "repeat when the others have no weight",
+			opts: []func(*cubism.PlayOption){cubism.WithoutRepeat(), cubism.WithWeights(0, 0, 1)},
+			check: func(t *testing.T, indices []int) {
+				for _, index := range indices {
+					assert.Equal(t, 2, index)
+				}
+			},
+		},
+		{
+			name: "alternate between two",
+			opts: []func(*cubism.PlayOption){cubism.WithoutRepeat(), cubism.WithWeights(1, 0, 1)},
+			check: func(t *testing.T, indices []int) {
+				for i := 1; i < len(indices); i++ {
+					assert.Equal(t, 2-indices[i-1], indices[i])
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			m := loadFixture(t)
+			indices := playIndices(t, m, "Greeting", 100, func() (int, error) {
+				return m.PlayRandomMotion("Greeting", tt.opts...)
+			})
+			tt.check(t, indices)
+		})
+	}
+}
+
+func TestPlayMotionErrors(t *testing.T) {
+	t.Parallel()
+	m := loadFixture(t)
+
+	tests := []struct {
+		name    string
+		play    func() (int, error)
+		wantErr error
+	}{
+		{name: "unknown group", play: func() (int, error) { return m.PlayMotion("Unknown", 0, false) }, wantErr: cubism.ErrMotionGroupNotFound},
+		{name: "negative index", play: func() (int, error) { return m.PlayMotion("Idle", -1, false) }, wantErr: cubism.ErrMotionIndexOutOfRange},
+		{name: "index out of range", play: func() (int, error) { return m.PlayMotion("Idle", 1, false) }, wantErr: cubism.ErrMotionIndexOutOfRange},
+		{name: "random from unknown group", play: func() (int, error) { return m.PlayRandomMotion("Unknown") }, wantErr: cubism.ErrMotionGroupNotFound},
+		{name: "next from unknown group", play: func() (int, error) { return m.PlayNextMotion("Unknown") }, wantErr: cubism.ErrMotionGroupNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.play()
+			assert.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+
+	// Invalid weights
+	_, err := m.PlayRandomMotion("Greeting", cubism.WithWeights(1, 1))
+	assert.Error(t, err)
+	_, err = m.PlayRandomMotion("Greeting", cubism.WithWeights(1, -1, 1))
+	assert.Error(t, err)
+	_, err = m.PlayRandomMotion("Greeting", cubism.WithWeights(0, 0, 0))
+	assert.Error(t, err)
+	_, ok := m.GetLastMotionIndex("Greeting")
+	assert.False(t, ok)
+}
@@ -0,0 +1,247 @@
+package cubism
+
+import (
+	"container/list"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/aethiopicuschan/cubism-go/motion"
+	"github.com/aethiopicuschan/cubism-go/sound"
+)
+
+// Reference to a motion in model3.json
+type motionRef struct {
+	// Absolute path of motion3.json
+	path        string
+	fadeInTime  float64
+	fadeOutTime float64
+	// Path of the sound relative to model3.json
+	sound string
+}
+
+type motionKey struct {
+	group string
+	index int
+}
+
+type motionCacheEntry struct {
+	key    motionKey
+	motion motion.Motion
+}
+
+// Storage of the motions
+// In lazy mode the motions are loaded on first use and cached
+// The refs are fixed after loading, while the loaded motions are guarded by mu
+type motionStore struct {
+	mu   sync.Mutex
+	refs map[string][]motionRef
+	load func(motionRef) (motion.Motion, error)
+	lazy bool
+	// Maximum number of cached motions, 0 means unlimited
+	limit int
+	// Motions loaded eagerly or preloaded are never evicted
+	pinned map[motionKey]motion.Motion
+	// Errors of the motions skipped by loadAll, which keep their indices
+	failed map[motionKey]error
+	cache  map[motionKey]*list.Element
+	// The front is the most recently used
+	lru *list.List
+	// Whether a layer is playing the motion, the sounds of such motions are released when they finish
+	inUse func(motionKey) bool
+	// Motions evicted from the cache, whose sounds are not released yet
+	evicted map[motionKey][]motion.Motion
+}
+
+func newMotionStore(refs map[string][]motionRef, load func(motionRef) (motion.Motion, error), lazy bool, limit int) *motionStore {
+	return &motionStore{
+		refs:   refs,
+		load:   load,
+		lazy:   lazy,
+		limit:  limit,
+		pinned: make(map[motionKey]motion.Motion),
+		failed: make(map[motionKey]error),
+		cache:  make(map[motionKey]*list.Element),
+		lru:    list.New(),
+		inUse: func(motionKey) bool {
+			return false
+		},
+		evicted: make(map[motionKey][]motion.Motion),
+	}
+}
+
+// Get the motion, loading it if necessary
+func (s *motionStore) get(group string, index int) (mtn motion.Motion, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.getLocked(group, index)
+}
+
+func (s *motionStore) getLocked(group string, index int) (mtn motion.Motion, err error) {
+	if err = s.check(group, index); err != nil {
+		return
+	}
+	key := motionKey{group: group, index: index}
+	if mtn, ok := s.pinned[key]; ok {
+		return mtn, nil
+	}
+	if err = s.failed[key]; err != nil {
+		return
+	}
+	if e, ok := s.cache[key]; ok {
+		s.lru.MoveToFront(e)
+		return e.Value.(*motionCacheEntry).motion, nil
+	}
+	mtn, err = s.load(s.refs[group][index])
+	if err != nil {
+		return
+	}
+	if !s.lazy {
+		s.pinned[key] = mtn
+		return
+	}
+	s.cache[key] = s.lru.PushFront(&motionCacheEntry{key: key, motion: mtn})
+	for s.limit > 0 && s.lru.Len() > s.limit {
+		e := s.lru.Back()
+		s.lru.Remove(e)
+		evicted := e.Value.(*motionCacheEntry)
+		delete(s.cache, evicted.key)
+		// The motion may have been handed out by this call or an earlier one of the same operation,
+		// so its sound is released by settle once the operation has registered what it plays
+		s.evicted[evicted.key] = append(s.evicted[evicted.key], evicted.motion)
+	}
+	return
+}
+
+// Release the sounds of the evicted motions which are not played
+// It is called once the motions got are registered as playing
+func (s *motionStore) settle() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.settleLocked()
+}
+
+func (s *motionStore) settleLocked() {
+	for key, motions := range s.evicted {
+		if s.inUse(key) {
+			continue
+		}
+		for _, mtn := range motions {
+			// The error is not fatal as the motion is discarded anyway
+			_ = releaseSound(mtn)
+		}
+		delete(s.evicted, key)
+	}
+}
+
+// Check whether the motion exists
+func (s *motionStore) check(group string, index int) error {
+	refs, ok := s.refs[group]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrMotionGroupNotFound, group)
+	}
+	if index < 0 || index >= len(refs) {
+		return fmt.Errorf("%w: %s has %d motions, got %d", ErrMotionIndexOutOfRange, group, len(refs), index)
+	}
+	return nil
+}
+
+// Load all the motions and keep them loaded
+// The motions for which skip returns nil keep their indices and return the error when they are got
+func (s *motionStore) loadAll(skip func(error) error) (err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for group, refs := range s.refs {
+		for i, ref := range refs {
+			key := motionKey{group: group, index: i}
+			var mtn motion.Motion
+			mtn, err = s.load(ref)
+			if err != nil {
+				s.failed[key] = err
+				if err = skip(err); err != nil {
+					return
+				}
+				continue
+			}
+			s.pinned[key] = mtn
+		}
+	}
+	return
+}
+
+// Load the motions of the groups and keep them loaded
+func (s *motionStore) preload(groups ...string) (err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	defer s.settleLocked()
+	for _, group := range groups {
+		refs, ok := s.refs[group]
+		if !ok {
+			return fmt.Errorf("%w: %s", ErrMotionGroupNotFound, group)
+		}
+		for i := range refs {
+			key := motionKey{group: group, index: i}
+			if _, ok := s.pinned[key]; ok {
+				continue
+			}
+			var mtn motion.Motion
+			mtn, err = s.getLocked(group, i)
+			if err != nil {
+				return
+			}
+			if e, ok := s.cache[key]; ok {
+				s.lru.Remove(e)
+				delete(s.cache, key)
+			}
+			s.pinned[key] = mtn
+		}
+	}
+	return
+}
+
+// Check whether the motion is loaded
+func (s *motionStore) isLoaded(group string, index int) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := motionKey{group: group, index: index}
+	_, pinned := s.pinned[key]
+	_, cached := s.cache[key]
+	return pinned || cached
+}
+
+// Stop and release the sounds of all the loaded motions
+func (s *motionStore) release() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	motions := []motion.Motion{}
+	for _, mtn := range s.pinned {
+		motions = append(motions, mtn)
+	}
+	for _, e := range s.cache {
+		motions = append(motions, e.Value.(*motionCacheEntry).motion)
+	}
+	for _, evicted := range s.evicted {
+		motions = append(motions, evicted...)
+	}
+	errs := []error{}
+	for _, mtn := range motions {
+		errs = append(errs, releaseSound(mtn))
+	}
+	s.pinned = make(map[motionKey]motion.Motion)
+	s.cache = make(map[motionKey]*list.Element)
+	s.evicted = make(map[motionKey][]motion.Motion)
+	s.lru.Init()
+	return errors.Join(errs...)
+}
+
+// Stop the sound of the motion and release it if it implements [sound.Releaser]
+func releaseSound(mtn motion.Motion) (err error) {
+	if mtn.LoadedSound == nil {
+		return
+	}
+	mtn.LoadedSound.Close()
+	if r, ok := mtn.LoadedSound.(sound.Releaser); ok {
+		err = r.Release()
+	}
+	return
+}
@@ -0,0 +1,18 @@
+package cubism
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/aethiopicuschan/cubism-go/internal/model"
+	"github.com/aethiopicuschan/cubism-go/motion"
+)
+
+// Write the motion as motion3.json
+// The counts of Meta are computed from the motion
+// The fade times and the sound are not written, as they belong to model3.json
+func WriteMotion(w io.Writer, mtn motion.Motion) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "\t")
+	return enc.Encode(model.NewMotionJson(mtn))
+}
@@ -0,0 +1,14 @@
+package cubism
+
+type Part struct {
+	Id string
+	// Display name from cdi3.json
+	Name    string
+	Opacity float32
+	// Index of the parent part, -1 if the part is at the root
+	Parent int
+	// Indices of the child parts
+	Children []int
+	// Indices of the drawables belonging to the part
+	Drawables []int
+}
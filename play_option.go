@@ -0,0 +1,59 @@
+package cubism
+
+// Options for playing a motion
+type PlayOption struct {
+	layerName    string
+	loop         *bool
+	fadeInOnLoop bool
+	noRepeat     bool
+	weights      []float64
+}
+
+func newPlayOption(opts []func(*PlayOption)) *PlayOption {
+	o := &PlayOption{
+		layerName: BaseMotionLayer,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// Play the motion on the layer added by [Model.AddMotionLayer] instead of the base layer
+func WithLayer(name string) func(*PlayOption) {
+	return func(o *PlayOption) {
+		o.layerName = name
+	}
+}
+
+// Override whether the motion loops
+// By default, Meta.Loop of motion3.json is used
+func WithLoop(loop bool) func(*PlayOption) {
+	return func(o *PlayOption) {
+		o.loop = &loop
+	}
+}
+
+// Fade in again on each iteration of a looping motion
+// By default, a looping motion only fades in on the first iteration
+func WithFadeInOnLoop() func(*PlayOption) {
+	return func(o *PlayOption) {
+		o.fadeInOnLoop = true
+	}
+}
+
+// Do not choose the motion played last in the group again with [Model.PlayRandomMotion]
+// It has no effect if the group has only one motion
+func WithoutRepeat() func(*PlayOption) {
+	return func(o *PlayOption) {
+		o.noRepeat = true
+	}
+}
+
+// Set the relative probabilities of the motions of the group for [Model.PlayRandomMotion]
+// The number of weights must match the number of motions, and the weights must not be negative
+func WithWeights(weights ...float64) func(*PlayOption) {
+	return func(o *PlayOption) {
+		o.weights = weights
+	}
+}
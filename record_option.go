@@ -0,0 +1,41 @@
+package cubism
+
+// Options for recording a model
+type RecordOption struct {
+	fps          float64
+	parameterIds []string
+	recordParts  bool
+	partIds      []string
+	tolerance    float64
+}
+
+// Set the number of samples per second, 30 by default
+// If it is 0, the values are sampled on every update
+func WithRecordFps(fps float64) func(*RecordOption) {
+	return func(o *RecordOption) {
+		o.fps = fps
+	}
+}
+
+// Record only the parameters with the IDs instead of all of them
+func WithRecordedParameters(ids ...string) func(*RecordOption) {
+	return func(o *RecordOption) {
+		o.parameterIds = ids
+	}
+}
+
+// Record the opacities of the parts with the IDs too, or of all the parts if no ID is given
+func WithRecordedParts(ids ...string) func(*RecordOption) {
+	return func(o *RecordOption) {
+		o.recordParts = true
+		o.partIds = ids
+	}
+}
+
+// Set how far the recorded curves may deviate from the samples, as a fraction of the range of each value
+// It is 0.005 by default, and larger values give fewer segments
+func WithTolerance(tolerance float64) func(*RecordOption) {
+	return func(o *RecordOption) {
+		o.tolerance = tolerance
+	}
+}
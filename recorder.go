@@ -0,0 +1,164 @@
+package cubism
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/aethiopicuschan/cubism-go/core"
+	"github.com/aethiopicuschan/cubism-go/core/parameter"
+	internalmotion "github.com/aethiopicuschan/cubism-go/internal/motion"
+	"github.com/aethiopicuschan/cubism-go/motion"
+)
+
+// Records the values of the parameters and the part opacities of a model on each [Model.Update]
+// Use [WriteMotion] to save the recorded motion as motion3.json, or [Model.PlayMotionData] to play it
+type Recorder struct {
+	model    *Model
+	option   *RecordOption
+	channels []recordChannel
+	times    []float64
+	// Time since the recording started, scaled by [Model.SetTimeScale]
+	elapsed    float64
+	nextSample float64
+	stopped    bool
+}
+
+// Samples of a parameter or a part opacity
+type recordChannel struct {
+	target string
+	id     string
+	// Range of the value, to which the tolerance is relative
+	span   float64
+	values []float64
+}
+
+// Start recording the parameters of the model
+// All the parameters are recorded unless [WithRecordedParameters] is given
+func (m *Model) StartRecording(opts ...func(*RecordOption)) (r *Recorder, err error) {
+	o := &RecordOption{
+		fps:       30,
+		tolerance: 0.005,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.closed {
+		err = ErrClosed
+		return
+	}
+	r = &Recorder{
+		model:  m,
+		option: o,
+	}
+	params := m.core.GetParameters(m.moc.ModelPtr)
+	for _, id := range o.parameterIds {
+		if !slices.ContainsFunc(params, func(p parameter.Parameter) bool { return p.Id == id }) {
+			r = nil
+			err = fmt.Errorf("%w: %s", ErrParameterNotFound, id)
+			return
+		}
+	}
+	for _, p := range params {
+		if o.parameterIds != nil && !slices.Contains(o.parameterIds, p.Id) {
+			continue
+		}
+		r.channels = append(r.channels, recordChannel{
+			target: "Parameter",
+			id:     p.Id,
+			span:   float64(p.Maximum - p.Minimum),
+		})
+	}
+	if o.recordParts {
+		for _, id := range o.partIds {
+			if !slices.ContainsFunc(m.parts, func(p Part) bool { return p.Id == id }) {
+				r = nil
+				err = fmt.Errorf("%w: %s", ErrPartNotFound, id)
+				return
+			}
+		}
+		for _, p := range m.parts {
+			if len(o.partIds) > 0 && !slices.Contains(o.partIds, p.Id) {
+				continue
+			}
+			r.channels = append(r.channels, recordChannel{
+				target: "PartOpacity",
+				id:     p.Id,
+				span:   1,
+			})
+		}
+	}
+	r.sample()
+	m.recorders = append(m.recorders, r)
+	return
+}
+
+// Sample the values at the current time
+// The model must be locked
+func (r *Recorder) sample() {
+	r.times = append(r.times, r.elapsed)
+	for i := range r.channels {
+		c := &r.channels[i]
+		var v float32
+		if c.target == "Parameter" {
+			v = r.model.core.GetParameterValue(r.model.moc.ModelPtr, c.id)
+		} else {
+			v = core.GetPartOpacity(r.model.core, r.model.moc.ModelPtr, c.id)
+		}
+		c.values = append(c.values, float64(v))
+	}
+}
+
+// Advance the time and sample the values if it is time to
+// The model must be locked
+func (r *Recorder) update(delta float64) {
+	r.elapsed += delta
+	if r.elapsed < r.nextSample || delta <= 0 {
+		return
+	}
+	r.sample()
+	if r.option.fps > 0 {
+		interval := 1 / r.option.fps
+		for r.nextSample <= r.elapsed {
+			r.nextSample += interval
+		}
+	}
+}
+
+// Stop recording and get the recorded motion
+// The samples are simplified into linear and Bezier segments within the tolerance
+func (r *Recorder) Stop() (mtn motion.Motion, err error) {
+	m := r.model
+	m.mu.Lock()
+	if r.stopped {
+		m.mu.Unlock()
+		err = errors.New("the recorder is already stopped")
+		return
+	}
+	r.stopped = true
+	if i := slices.Index(m.recorders, r); i != -1 {
+		m.recorders = slices.Delete(m.recorders, i, i+1)
+	}
+	// Sample the values at the end unless the last update was just sampled
+	if !m.closed && r.elapsed > r.times[len(r.times)-1] {
+		r.sample()
+	}
+	m.mu.Unlock()
+
+	fps := r.option.fps
+	if fps <= 0 {
+		fps = 30
+	}
+	b := motion.NewBuilder(r.times[len(r.times)-1]).Fps(fps)
+	// Without an update, there is nothing to record but the values at the start
+	if len(r.times) > 1 {
+		for _, c := range r.channels {
+			cb := motion.NewCurveBuilder(c.target, c.id, motion.Point{Time: 0, Value: c.values[0]})
+			internalmotion.Simplify(cb, r.times, c.values, r.option.tolerance*c.span)
+			b.Curve(cb)
+		}
+	}
+	return b.Build()
+}
@@ -0,0 +1,76 @@
+package cubism_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aethiopicuschan/cubism-go"
+	"github.com/aethiopicuschan/cubism-go/core/fake"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecorder(t *testing.T) {
+	t.Parallel()
+	m := loadFixture(t)
+
+	r, err := m.StartRecording(cubism.WithRecordFps(60), cubism.WithRecordedParameters("ParamAngleX"), cubism.WithRecordedParts("PartArmA"))
+	require.NoError(t, err)
+	_, err = m.PlayMotion("Idle", 0, false)
+	require.NoError(t, err)
+	recorded := []float32{}
+	for i := 0; i < 150; i++ {
+		m.Update(1.0 / 60)
+		recorded = append(recorded, m.GetParameterValue("ParamAngleX"))
+	}
+	mtn, err := r.Stop()
+	require.NoError(t, err)
+	_, err = r.Stop()
+	assert.Error(t, err)
+
+	assert.InDelta(t, 2.5, mtn.Meta.Duration, 1e-9)
+	require.Len(t, mtn.Curves, 2)
+	assert.Equal(t, "ParamAngleX", mtn.Curves[0].Id)
+	assert.Equal(t, "PartOpacity", mtn.Curves[1].Target)
+	assert.Less(t, len(mtn.Curves[0].Segments), len(recorded)/4)
+
+	// The recorded motion plays back the take within the tolerance
+	dir := t.TempDir()
+	path, err := fake.WriteFixture(dir)
+	require.NoError(t, err)
+	f, err := os.Create(filepath.Join(dir, "motions", "Tap.motion3.json"))
+	require.NoError(t, err)
+	require.NoError(t, cubism.WriteMotion(f, mtn))
+	require.NoError(t, f.Close())
+	csm := cubism.NewCubismFromCore(fake.NewCore(fake.FixtureModel()))
+	playback, err := csm.LoadModel(path, cubism.WithoutSounds())
+	require.NoError(t, err)
+	_, err = playback.PlayMotion("TapBody", 0, false)
+	require.NoError(t, err)
+	for i, want := range recorded {
+		playback.Update(1.0 / 60)
+		assert.InDelta(t, want, playback.GetParameterValue("ParamAngleX"), 0.005*60, "frame %d", i)
+	}
+}
+
+func TestRecorderErrors(t *testing.T) {
+	t.Parallel()
+	m := loadFixture(t)
+
+	_, err := m.StartRecording(cubism.WithRecordedParameters("ParamUnknown"))
+	assert.ErrorIs(t, err, cubism.ErrParameterNotFound)
+	_, err = m.StartRecording(cubism.WithRecordedParts("PartUnknown"))
+	assert.ErrorIs(t, err, cubism.ErrPartNotFound)
+
+	// The samples taken before the model is closed are kept
+	r, err := m.StartRecording()
+	require.NoError(t, err)
+	m.Update(0.1)
+	require.NoError(t, m.Close())
+	mtn, err := r.Stop()
+	require.NoError(t, err)
+	assert.Len(t, mtn.Curves, len(fake.FixtureModel().Parameters))
+	_, err = m.StartRecording()
+	assert.ErrorIs(t, err, cubism.ErrClosed)
+}
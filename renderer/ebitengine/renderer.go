@@ -20,10 +20,12 @@ type Renderer struct {
 	fb, mb, surface *ebiten.Image
 	textureMap      map[string]*ebiten.Image
 	model           *cubism.Model
+	snapshot        *cubism.Snapshot
 	drawables       []cubism.Drawable
 	vertices        [][]ebiten.Vertex
 	maskShader      *ebiten.Shader
 	final           image.Rectangle
+	disposed        bool
 }
 
 // Constructor for the [Renderer] struct
@@ -56,27 +58,56 @@ func NewRenderer(model *cubism.Model) (r *Renderer, err error) {
 }
 
 // Update the renderer
+// Returns [cubism.ErrClosed] if the renderer has been disposed or the model has been closed
+// The buffers are reused across frames and only the drawables whose vertices changed are rebuilt
 func (r *Renderer) Update() error {
+	if r.disposed || r.model.IsClosed() {
+		return cubism.ErrClosed
+	}
 	r.model.Update(1.0 / float64(ebiten.TPS()))
-	r.drawables = r.model.GetDrawables()
-	vertices := make([][]ebiten.Vertex, 0)
-	for _, d := range r.drawables {
-		v := make([]ebiten.Vertex, 0)
-		for i := 0; i < len(d.VertexPositions); i++ {
-			v = append(v, ebiten.Vertex{
-				DstX:   (d.VertexPositions[i].X + 1) * float32(r.surface.Bounds().Dx()) / 2,
-				DstY:   (d.VertexPositions[i].Y*-1 + 1) * float32(r.surface.Bounds().Dy()) / 2,
-				SrcX:   d.VertexUvs[i].X * float32(r.textureMap[d.Texture].Bounds().Dx()),
-				SrcY:   (1 - d.VertexUvs[i].Y) * float32(r.textureMap[d.Texture].Bounds().Dy()),
-				ColorR: 1,
-				ColorG: 1,
-				ColorB: 1,
-				ColorA: 1,
-			})
+	// Draw the frame of the snapshot, so that another goroutine may change the model in the meantime
+	var last uint64
+	if r.snapshot != nil {
+		last = r.snapshot.GetFrame()
+	}
+	snapshot := r.model.SnapshotInto(r.snapshot)
+	if snapshot == nil {
+		return cubism.ErrClosed
+	}
+	r.snapshot = snapshot
+	r.drawables = snapshot.GetDrawables()
+	// The dynamic flags only describe the last update, so everything is rebuilt if an update was missed
+	all := r.vertices == nil || snapshot.GetFrame() != last+1
+	if r.vertices == nil {
+		r.vertices = make([][]ebiten.Vertex, len(r.drawables))
+	}
+	width, height := float32(r.surface.Bounds().Dx()), float32(r.surface.Bounds().Dy())
+	for i, d := range r.drawables {
+		if !all && !d.DynamicFlag.VertexPositionsDidChange {
+			continue
+		}
+		v := r.vertices[i]
+		if v == nil {
+			// The UVs never change, so they are only set once
+			v = make([]ebiten.Vertex, len(d.VertexPositions))
+			texture := r.textureMap[d.Texture].Bounds()
+			for j, uv := range d.VertexUvs {
+				v[j] = ebiten.Vertex{
+					SrcX:   uv.X * float32(texture.Dx()),
+					SrcY:   (1 - uv.Y) * float32(texture.Dy()),
+					ColorR: 1,
+					ColorG: 1,
+					ColorB: 1,
+					ColorA: 1,
+				}
+			}
+			r.vertices[i] = v
+		}
+		for j, p := range d.VertexPositions {
+			v[j].DstX = (p.X + 1) * width / 2
+			v[j].DstY = (p.Y*-1 + 1) * height / 2
 		}
-		vertices = append(vertices, v)
 	}
-	r.vertices = vertices
 	return nil
 }
 
@@ -129,6 +160,9 @@ func (r *Renderer) Draw(screen *ebiten.Image, opts ...func(*DrawOption)) {
 	for _, o := range opts {
 		o(opt)
 	}
+	if r.disposed || r.snapshot == nil || r.model.IsClosed() {
+		return
+	}
 
 	last_options := &ebiten.DrawImageOptions{}
 	// First, adjust to the screen size
@@ -146,14 +180,14 @@ func (r *Renderer) Draw(screen *ebiten.Image, opts ...func(*DrawOption)) {
 	last_options.GeoM.Translate(x, y)
 	r.final = image.Rect(int(x), int(y), int(x+width), int(y+height))
 	// Set Alpha
-	last_options.ColorScale.SetA(r.model.GetOpacity())
+	last_options.ColorScale.SetA(r.snapshot.GetOpacity())
 
 	if opt.hidden {
 		return
 	}
 
 	r.surface.Fill(opt.background)
-	sortedIndices := r.model.GetSortedIndices()
+	sortedIndices := r.snapshot.GetSortedIndices()
 	for _, index := range sortedIndices {
 		d := r.drawables[index]
 		if !d.DynamicFlag.IsVisible {
@@ -203,13 +237,20 @@ func (r *Renderer) GetModel() *cubism.Model {
 
 // Perform collision detection
 func (r *Renderer) IsHit(x, y int, id string) (hit bool, err error) {
+	if r.disposed {
+		err = cubism.ErrClosed
+		return
+	}
+	if r.snapshot == nil {
+		return
+	}
 	// Out of bounds
 	if r.final.Min.X > x || x > r.final.Max.X || r.final.Min.Y > y || y > r.final.Max.Y {
 		return
 	}
 
 	// Get the Drawable
-	d, err := r.model.GetDrawable(id)
+	d, err := r.snapshot.GetDrawable(id)
 	if err != nil {
 		return
 	}
@@ -246,3 +287,23 @@ func (r *Renderer) IsHit(x, y int, id string) (hit bool, err error) {
 
 	return
 }
+
+// Dispose the renderer and deallocate its images and shader
+// The model is not closed, so it can be used with another renderer
+func (r *Renderer) Dispose() {
+	if r.disposed {
+		return
+	}
+	r.disposed = true
+	for _, img := range r.textureMap {
+		img.Deallocate()
+	}
+	r.fb.Deallocate()
+	r.mb.Deallocate()
+	r.surface.Deallocate()
+	r.maskShader.Deallocate()
+	r.textureMap = nil
+	r.snapshot = nil
+	r.drawables = nil
+	r.vertices = nil
+}
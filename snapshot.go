@@ -0,0 +1,73 @@
+package cubism
+
+import "fmt"
+
+// An immutable frame of a model taken by [Model.Snapshot]
+// It owns copies of the data changed by [Model.Update], so it can be read from any goroutine
+type Snapshot struct {
+	frame         uint64
+	opacity       float32
+	sortedIndices []int
+	drawables     []Drawable
+	drawablesMap  map[string]int
+}
+
+// Take a snapshot of the current frame
+// The vertex positions, opacities, dynamic flags and drawing order are copied,
+// while the data which never changes, such as the UVs and the indices, is shared with the model
+// Returns nil if the model has been closed
+func (m *Model) Snapshot() *Snapshot {
+	return m.SnapshotInto(nil)
+}
+
+// Take a snapshot of the current frame reusing the buffers of dst
+// If dst is nil, a new snapshot is allocated
+// The previous content of dst is overwritten, so it must not be read by another goroutine in the meantime
+// Returns nil if the model has been closed
+func (m *Model) SnapshotInto(dst *Snapshot) (s *Snapshot) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.closed {
+		return
+	}
+	s = dst
+	if s == nil {
+		s = &Snapshot{}
+	}
+	s.frame = m.frame
+	s.opacity = m.opacity
+	s.sortedIndices = append(s.sortedIndices[:0], m.sortedIndices...)
+	s.drawables = copyDrawables(s.drawables, m.drawables)
+	s.drawablesMap = m.drawablesMap
+	return
+}
+
+// Get the frame of the model when the snapshot was taken
+// It is the value of [Model.GetFrame]
+func (s *Snapshot) GetFrame() uint64 {
+	return s.frame
+}
+
+// Get the opacity of the model
+func (s *Snapshot) GetOpacity() float32 {
+	return s.opacity
+}
+
+// Get the sorted drawing order indices
+func (s *Snapshot) GetSortedIndices() []int {
+	return s.sortedIndices
+}
+
+// Get the drawables
+func (s *Snapshot) GetDrawables() []Drawable {
+	return s.drawables
+}
+
+// Get the Drawable with the specified ID
+func (s *Snapshot) GetDrawable(id string) (d Drawable, err error) {
+	if i, ok := s.drawablesMap[id]; ok {
+		return s.drawables[i], nil
+	}
+	err = fmt.Errorf("%w: %s", ErrDrawableNotFound, id)
+	return
+}
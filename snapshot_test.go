@@ -0,0 +1,87 @@
+package cubism_test
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/aethiopicuschan/cubism-go"
+	"github.com/aethiopicuschan/cubism-go/core/fake"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnapshot(t *testing.T) {
+	t.Parallel()
+	m := loadFixture(t)
+	m.SetParameterValue("ParamAngleX", 10)
+	m.Update(0)
+
+	s := m.Snapshot()
+	require.NotNil(t, s)
+	assert.Equal(t, m.GetSortedIndices(), s.GetSortedIndices())
+	before, err := s.GetDrawable("HitAreaBody")
+	require.NoError(t, err)
+	_, err = s.GetDrawable("Unknown")
+	assert.ErrorIs(t, err, cubism.ErrDrawableNotFound)
+
+	// The snapshot is not affected by the next update
+	m.SetParameterValue("ParamAngleX", -10)
+	m.Update(0)
+	after, err := s.GetDrawable("HitAreaBody")
+	require.NoError(t, err)
+	assert.Equal(t, before.VertexPositions, after.VertexPositions)
+	current, err := m.GetDrawable("HitAreaBody")
+	require.NoError(t, err)
+	assert.NotEqual(t, before.VertexPositions, current.VertexPositions)
+
+	require.NoError(t, m.Close())
+	assert.Nil(t, m.Snapshot())
+}
+
+// Run with -race to check the concurrency guarantees of the model
+func TestSnapshotConcurrent(t *testing.T) {
+	t.Parallel()
+	m := loadFixture(t)
+	base := fake.FixtureModel().Drawables
+
+	var wg sync.WaitGroup
+	done := make(chan struct{})
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer close(done)
+		_, err := m.PlayMotion("Idle", 0, true)
+		assert.NoError(t, err)
+		for i := 0; i < 200; i++ {
+			m.SetParameterValue("ParamAngleX", float32(i%60-30))
+			m.Update(1.0 / 60)
+		}
+	}()
+	for r := 0; r < 2; r++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				s := m.Snapshot()
+				for i, d := range s.GetDrawables() {
+					// All the vertices of a drawable are translated together, so a torn frame has different offsets
+					dx := d.VertexPositions[0].X - base[i].VertexPositions[0].X
+					dy := d.VertexPositions[0].Y - base[i].VertexPositions[0].Y
+					for j, v := range d.VertexPositions {
+						assert.InDelta(t, dx, v.X-base[i].VertexPositions[j].X, 1e-4)
+						assert.InDelta(t, dy, v.Y-base[i].VertexPositions[j].Y, 1e-4)
+					}
+				}
+				m.GetParameters()
+				m.GetParts()
+				m.GetMotions("Idle")
+			}
+		}()
+	}
+	wg.Wait()
+}
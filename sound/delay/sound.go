@@ -83,10 +83,29 @@ func (s *Sound) Play() (err error) {
 }
 
 func (s *Sound) Close() {
+	// Nothing to stop if the sound has never been played
+	if s.ctrl == nil {
+		return
+	}
 	s.ctrl.Paused = true
 	s.streamer.Seek(0)
 }
 
+// Release the decoded sound
+func (s *Sound) Release() (err error) {
+	if s.ctrl == nil {
+		return
+	}
+	// Detach the streamer so that the speaker drops it
+	speaker.Lock()
+	s.ctrl.Streamer = nil
+	speaker.Unlock()
+	err = s.streamer.Close()
+	s.streamer = nil
+	s.ctrl = nil
+	return
+}
+
 func detectFormat(fp string) (f string, err error) {
 	ext := filepath.Ext(fp)
 	switch ext {
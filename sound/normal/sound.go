@@ -68,6 +68,10 @@ func (s *Sound) Decode(fp string, buf []byte) (err error) {
 }
 
 func (s *Sound) Play() (err error) {
+	// Nothing to play once the sound is released
+	if s.ctrl == nil {
+		return
+	}
 	s.streamer.Seek(0)
 	s.ctrl.Paused = false
 	speaker.Play(s.ctrl)
@@ -75,10 +79,29 @@ func (s *Sound) Play() (err error) {
 }
 
 func (s *Sound) Close() {
+	// Nothing to stop once the sound is released
+	if s.ctrl == nil {
+		return
+	}
 	s.ctrl.Paused = true
 	s.streamer.Seek(0)
 }
 
+// Release the decoded sound
+func (s *Sound) Release() (err error) {
+	if s.ctrl == nil {
+		return
+	}
+	// Detach the streamer so that the speaker drops it
+	speaker.Lock()
+	s.ctrl.Streamer = nil
+	speaker.Unlock()
+	err = s.streamer.Close()
+	s.streamer = nil
+	s.ctrl = nil
+	return
+}
+
 func detectFormat(fp string) (f string, err error) {
 	ext := filepath.Ext(fp)
 	switch ext {
@@ -11,3 +11,14 @@ type Sound interface {
 	// Stop the sound
 	Close()
 }
+
+/*
+Optional interface for releasing the resources held by a [Sound]
+If a Sound implements it, Release is called when the model is closed
+or when its motion is discarded by the cache of the lazily loaded motions.
+*/
+type Releaser interface {
+	// Release the resources
+	// The sound must not be played afterwards
+	Release() error
+}
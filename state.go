@@ -0,0 +1,330 @@
+package cubism
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/aethiopicuschan/cubism-go/core"
+	"github.com/aethiopicuschan/cubism-go/internal/blink"
+	internalmotion "github.com/aethiopicuschan/cubism-go/internal/motion"
+)
+
+// State of a model saved by [Model.SaveState]
+// It only holds plain values, so it can be serialized, for example to JSON
+// Expressions are not part of it, because the model loads them but never applies them,
+// so any values set from them are kept in Parameters
+type State struct {
+	// Values of the parameters by ID
+	Parameters map[string]float32
+	// Opacities of the parts by ID
+	PartOpacities map[string]float32
+	// Motion layers in the order they were added, the first one is the base layer
+	MotionLayers []MotionLayerState
+	AutoBlink    BlinkState
+	TimeScale    float64
+}
+
+// State of a motion layer
+type MotionLayerState struct {
+	Name   string
+	Blend  MotionBlend
+	Weight float64
+	// Motions in the order they were started
+	Motions []MotionState
+}
+
+// State of a playing motion
+type MotionState struct {
+	Id int
+	// Group and index of the motion in model3.json
+	Group        string
+	Index        int
+	Time         float64
+	Speed        float64
+	Weight       float64
+	Paused       bool
+	Loop         bool
+	FadeInOnLoop bool
+	// Number of times the motion has looped
+	Iteration int
+}
+
+// State of Auto Blink
+type BlinkState struct {
+	Enabled bool
+	// Progress of the blinking
+	Phase            int
+	CurrentTime      float64
+	PhaseStartTime   float64
+	NextBlinkingTime float64
+}
+
+// Difference between two states, holding the values of the newer one which changed
+type StateDiff struct {
+	Parameters    map[string]float32
+	PartOpacities map[string]float32
+	// The motion layers of the newer state, nil if they did not change
+	MotionLayers []MotionLayerState
+	// nil if it did not change
+	AutoBlink *BlinkState
+	// nil if it did not change
+	TimeScale *float64
+}
+
+// Get the changes from one state to another
+// Applying the difference by [Model.ApplyStateDiff] to a model in the state from brings it to the state to
+func DiffStates(from, to State) (d StateDiff) {
+	d.Parameters = diffValues(from.Parameters, to.Parameters)
+	d.PartOpacities = diffValues(from.PartOpacities, to.PartOpacities)
+	if !reflect.DeepEqual(from.MotionLayers, to.MotionLayers) {
+		d.MotionLayers = to.MotionLayers
+	}
+	if from.AutoBlink != to.AutoBlink {
+		d.AutoBlink = &to.AutoBlink
+	}
+	if from.TimeScale != to.TimeScale {
+		d.TimeScale = &to.TimeScale
+	}
+	return
+}
+
+func diffValues(from, to map[string]float32) (diff map[string]float32) {
+	diff = map[string]float32{}
+	for id, value := range to {
+		if old, ok := from[id]; !ok || old != value {
+			diff[id] = value
+		}
+	}
+	return
+}
+
+// Check whether nothing changed
+func (d StateDiff) IsEmpty() bool {
+	return len(d.Parameters) == 0 && len(d.PartOpacities) == 0 && d.MotionLayers == nil && d.AutoBlink == nil && d.TimeScale == nil
+}
+
+// Save the parameters, the part opacities, the playing motions, Auto Blink and the time scale
+// The motions played by [Model.PlayMotionData] are left out
+func (m *Model) SaveState() (s State, err error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.closed {
+		err = ErrClosed
+		return
+	}
+	s = State{
+		Parameters:    map[string]float32{},
+		PartOpacities: map[string]float32{},
+		TimeScale:     m.timeScale,
+	}
+	for _, p := range m.core.GetParameters(m.moc.ModelPtr) {
+		s.Parameters[p.Id] = p.Current
+	}
+	for _, p := range m.parts {
+		s.PartOpacities[p.Id] = core.GetPartOpacity(m.core, m.moc.ModelPtr, p.Id)
+	}
+	for _, l := range m.motionLayers {
+		ls := MotionLayerState{
+			Name:   l.name,
+			Blend:  MotionBlendOverride,
+			Weight: l.manager.GetManagerWeight(),
+		}
+		if l.manager.GetBlend() == internalmotion.BlendAdditive {
+			ls.Blend = MotionBlendAdditive
+		}
+		for _, e := range l.manager.GetStates() {
+			key, ok := m.playingMotions[e.Id]
+			if !ok {
+				// Played by [Model.PlayMotionData], so it cannot be loaded again
+				continue
+			}
+			ls.Motions = append(ls.Motions, MotionState{
+				Id:           e.Id,
+				Group:        key.group,
+				Index:        key.index,
+				Time:         e.Time,
+				Speed:        e.Speed,
+				Weight:       e.Weight,
+				Paused:       e.Paused,
+				Loop:         e.Loop,
+				FadeInOnLoop: e.FadeInOnLoop,
+				Iteration:    e.Iteration,
+			})
+		}
+		s.MotionLayers = append(s.MotionLayers, ls)
+	}
+	if m.blinkManager != nil {
+		b := m.blinkManager.GetState()
+		s.AutoBlink = BlinkState{
+			Enabled:          true,
+			Phase:            b.State,
+			CurrentTime:      b.CurrentTime,
+			PhaseStartTime:   b.StateStartTime,
+			NextBlinkingTime: b.NextBlinkingTime,
+		}
+	}
+	return
+}
+
+// Bring the model back to the state saved by [Model.SaveState]
+// The playing motions are stopped and those of the state are played from where they were, without their sounds
+// The motions which are also in the state are not reported as finished, and [Model.WaitMotion] keeps waiting for them
+func (m *Model) RestoreState(s State) error {
+	if len(s.MotionLayers) == 0 {
+		return errors.New("the state has no motion layers")
+	}
+	return m.ApplyStateDiff(StateDiff{
+		Parameters:    s.Parameters,
+		PartOpacities: s.PartOpacities,
+		MotionLayers:  s.MotionLayers,
+		AutoBlink:     &s.AutoBlink,
+		TimeScale:     &s.TimeScale,
+	})
+}
+
+// Apply the changes got by [DiffStates]
+// Nothing is changed if an ID, a motion or a layer of the difference is not found
+func (m *Model) ApplyStateDiff(d StateDiff) error {
+	pending, err := m.applyStateDiff(d)
+	runPending(pending)
+	return err
+}
+
+func (m *Model) applyStateDiff(d StateDiff) (pending []func(), err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.closed {
+		err = ErrClosed
+		return
+	}
+	// The motions loaded for the restored layers may evict each other from the cache,
+	// so the evicted sounds are only released once the restored motions are registered
+	defer m.motions.settle()
+
+	// Check everything before changing anything
+	params := map[string]bool{}
+	for _, p := range m.core.GetParameters(m.moc.ModelPtr) {
+		params[p.Id] = true
+	}
+	for id := range d.Parameters {
+		if !params[id] {
+			err = fmt.Errorf("%w: %s", ErrParameterNotFound, id)
+			return
+		}
+	}
+	parts := map[string]bool{}
+	for _, p := range m.parts {
+		parts[p.Id] = true
+	}
+	for id := range d.PartOpacities {
+		if !parts[id] {
+			err = fmt.Errorf("%w: %s", ErrPartNotFound, id)
+			return
+		}
+	}
+	var layers []*motionLayer
+	if d.MotionLayers != nil {
+		if layers, err = m.restoreMotionLayers(d.MotionLayers); err != nil {
+			return
+		}
+	}
+
+	if layers != nil {
+		restored := map[int]bool{}
+		for _, ls := range d.MotionLayers {
+			for _, ms := range ls.Motions {
+				restored[ms.Id] = true
+			}
+		}
+		// Stopping the motions restores the parameters, so it is done first
+		for _, l := range m.motionLayers {
+			for _, id := range l.manager.GetIds() {
+				if !restored[id] {
+					m.closeMotion(l.manager, id)
+					continue
+				}
+				// The motion goes on in the restored layers, so it does not finish and its waiters are kept
+				l.manager.Close(id)
+				m.forgetMotion(id)
+			}
+		}
+		m.deactivateMotions()
+		m.motionLayers = layers
+		if m.playingMotions == nil {
+			m.playingMotions = make(map[int]motionKey)
+		}
+		for _, ls := range d.MotionLayers {
+			for _, ms := range ls.Motions {
+				m.playingMotions[ms.Id] = motionKey{group: ms.Group, index: ms.Index}
+			}
+		}
+		// The values of the motions are saved again on the next update
+		m.motionsActive = false
+	}
+	for id, value := range d.Parameters {
+		m.core.SetParameterValue(m.moc.ModelPtr, id, value)
+	}
+	for id, value := range d.PartOpacities {
+		m.core.SetPartOpacity(m.moc.ModelPtr, id, value)
+	}
+	if b := d.AutoBlink; b != nil {
+		m.blinkManager = nil
+		if b.Enabled {
+			m.enableAutoBlink()
+		}
+		if m.blinkManager != nil {
+			m.blinkManager.SetState(blink.State{
+				State:            b.Phase,
+				CurrentTime:      b.CurrentTime,
+				StateStartTime:   b.PhaseStartTime,
+				NextBlinkingTime: b.NextBlinkingTime,
+			})
+		}
+	}
+	if d.TimeScale != nil {
+		m.timeScale = *d.TimeScale
+	}
+	pending = m.takePending()
+	return
+}
+
+// Build the motion layers of the states, without touching the current ones
+// The model must be locked
+func (m *Model) restoreMotionLayers(states []MotionLayerState) (layers []*motionLayer, err error) {
+	if len(states) == 0 || states[0].Name != BaseMotionLayer {
+		err = errors.New("the first motion layer must be the base layer")
+		return
+	}
+	names := map[string]bool{}
+	lastId := m.lastMotionId
+	for _, ls := range states {
+		if names[ls.Name] {
+			err = fmt.Errorf("%w: %s", ErrMotionLayerExists, ls.Name)
+			return
+		}
+		names[ls.Name] = true
+		l := m.newMotionLayer(ls.Name, ls.Blend)
+		l.manager.SetManagerWeight(ls.Weight)
+		for _, ms := range ls.Motions {
+			mtn, err := m.motions.get(ms.Group, ms.Index)
+			if err != nil {
+				return nil, err
+			}
+			l.manager.StartWithState(mtn, internalmotion.EntryState{
+				Id:           ms.Id,
+				Time:         ms.Time,
+				Speed:        ms.Speed,
+				Weight:       ms.Weight,
+				Paused:       ms.Paused,
+				Loop:         ms.Loop,
+				FadeInOnLoop: ms.FadeInOnLoop,
+				Iteration:    ms.Iteration,
+			})
+			lastId = max(lastId, ms.Id)
+		}
+		layers = append(layers, l)
+	}
+	m.lastMotionId = lastId
+	return
+}
@@ -0,0 +1,257 @@
+package cubism_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/aethiopicuschan/cubism-go"
+	"github.com/aethiopicuschan/cubism-go/core/fake"
+	"github.com/aethiopicuschan/cubism-go/sound"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSaveState(t *testing.T) {
+	t.Parallel()
+	m := loadFixture(t)
+
+	require.NoError(t, m.AddMotionLayer("face", cubism.MotionBlendAdditive))
+	require.NoError(t, m.SetMotionLayerWeight("face", 0.5))
+	id, err := m.PlayMotion("Idle", 0, true)
+	require.NoError(t, err)
+	tap, err := m.PlayMotionOnLayer("face", "TapBody", 0, false)
+	require.NoError(t, err)
+	m.EnableAutoBlink()
+	m.Update(0.5)
+	require.NoError(t, m.PauseMotion(id))
+	m.SetParameterValue("ParamAngleX", 12)
+	saved, err := m.SaveState()
+	require.NoError(t, err)
+
+	assert.Equal(t, float32(12), saved.Parameters["ParamAngleX"])
+	assert.Contains(t, saved.PartOpacities, "PartArmA")
+	assert.True(t, saved.AutoBlink.Enabled)
+	assert.Equal(t, 1.0, saved.TimeScale)
+	assert.Equal(t, []cubism.MotionLayerState{
+		{
+			Name:   cubism.BaseMotionLayer,
+			Blend:  cubism.MotionBlendOverride,
+			Weight: 1,
+			Motions: []cubism.MotionState{
+				{Id: id, Group: "Idle", Index: 0, Time: 0.5, Speed: 1, Weight: 1, Paused: true, Loop: true},
+			},
+		},
+		{
+			Name:   "face",
+			Blend:  cubism.MotionBlendAdditive,
+			Weight: 0.5,
+			Motions: []cubism.MotionState{
+				{Id: tap, Group: "TapBody", Index: 0, Time: 0.5, Speed: 1, Weight: 1},
+			},
+		},
+	}, saved.MotionLayers)
+
+	// The state survives serialization
+	b, err := json.Marshal(saved)
+	require.NoError(t, err)
+	var decoded cubism.State
+	require.NoError(t, json.Unmarshal(b, &decoded))
+	assert.Equal(t, saved, decoded)
+}
+
+func TestRestoreState(t *testing.T) {
+	t.Parallel()
+	m := loadFixture(t)
+
+	id, err := m.PlayMotion("Idle", 0, true)
+	require.NoError(t, err)
+	m.Update(0.5)
+	require.NoError(t, m.PauseMotion(id))
+	m.SetParameterValue("ParamAngleX", 12)
+	saved, err := m.SaveState()
+	require.NoError(t, err)
+
+	finished := []int{}
+	m.OnMotionFinished(func(id int) {
+		finished = append(finished, id)
+	})
+	m.StopMotion(id)
+	require.NoError(t, m.AddMotionLayer("face", cubism.MotionBlendAdditive))
+	other, err := m.PlayMotionOnLayer("face", "TapBody", 0, false)
+	require.NoError(t, err)
+	m.SetParameterValue("ParamAngleX", -5)
+	m.EnableAutoBlink()
+	m.SetTimeScale(2)
+
+	require.NoError(t, m.RestoreState(saved))
+	assert.Equal(t, []int{id, other}, finished)
+	restored, err := m.SaveState()
+	require.NoError(t, err)
+	assert.Equal(t, saved, restored)
+	assert.Equal(t, []string{cubism.BaseMotionLayer}, m.GetMotionLayerNames())
+
+	// The restored motion continues from where it was
+	require.NoError(t, m.ResumeMotion(id))
+	m.Update(0.25)
+	current, err := m.GetMotionTime(id)
+	require.NoError(t, err)
+	assert.Equal(t, 0.75, current)
+	// New motions do not reuse the restored IDs
+	next, err := m.PlayMotion("TapBody", 0, false)
+	require.NoError(t, err)
+	assert.Greater(t, next, other)
+}
+
+func TestRestoreStateWaiters(t *testing.T) {
+	t.Parallel()
+	m := loadFixture(t)
+
+	id, err := m.PlayMotion("Idle", 0, true)
+	require.NoError(t, err)
+	saved, err := m.SaveState()
+	require.NoError(t, err)
+	m.Update(0.5)
+
+	finished := []int{}
+	m.OnMotionFinished(func(id int) {
+		finished = append(finished, id)
+	})
+	wait := m.WaitMotion(id)
+	// The motion goes on after restoring, so it does not finish
+	require.NoError(t, m.RestoreState(saved))
+	assert.Empty(t, finished)
+	select {
+	case <-wait:
+		t.Fatal("the waiter is released")
+	default:
+	}
+
+	m.StopMotion(id)
+	assert.Equal(t, []int{id}, finished)
+	<-wait
+}
+
+func TestRestoreStateCacheLimit(t *testing.T) {
+	t.Parallel()
+	path, err := fake.WriteFixture(t.TempDir())
+	require.NoError(t, err)
+	addGreetingSounds(t, path)
+
+	sounds := []*releasedSound{}
+	csm := cubism.NewCubismFromCore(fake.NewCore(fake.FixtureModel()))
+	csm.LoadSound = func(fp string) (s sound.Sound, err error) {
+		rs := &releasedSound{fp: fp}
+		sounds = append(sounds, rs)
+		return rs, nil
+	}
+	m, err := csm.LoadModel(path, cubism.WithLazyMotions(), cubism.WithMotionCacheLimit(1))
+	require.NoError(t, err)
+
+	ids := []int{}
+	for i := 0; i < 3; i++ {
+		id, err := m.PlayMotion("Greeting", i, true)
+		require.NoError(t, err)
+		ids = append(ids, id)
+	}
+	saved, err := m.SaveState()
+	require.NoError(t, err)
+	require.Len(t, saved.MotionLayers[0].Motions, 3)
+	for _, id := range ids {
+		m.StopMotion(id)
+	}
+
+	// More motions are restored than the cache holds
+	require.NoError(t, m.RestoreState(saved))
+	// The sounds are retriggered on each loop
+	for i := 0; i < 30; i++ {
+		m.Update(0.1)
+	}
+	for _, id := range ids {
+		m.StopMotion(id)
+	}
+	for _, s := range sounds {
+		assert.False(t, s.usedAfterRelease, s.fp)
+	}
+}
+
+func TestDiffStates(t *testing.T) {
+	t.Parallel()
+	m := loadFixture(t)
+
+	from, err := m.SaveState()
+	require.NoError(t, err)
+	assert.True(t, cubism.DiffStates(from, from).IsEmpty())
+
+	m.SetParameterValue("ParamAngleX", 12)
+	m.SetPartOpacity("PartArmB", 1)
+	_, err = m.PlayMotion("Idle", 0, true)
+	require.NoError(t, err)
+	m.SetTimeScale(0.5)
+	to, err := m.SaveState()
+	require.NoError(t, err)
+
+	diff := cubism.DiffStates(from, to)
+	assert.Equal(t, map[string]float32{"ParamAngleX": 12}, diff.Parameters)
+	assert.Equal(t, map[string]float32{"PartArmB": 1}, diff.PartOpacities)
+	assert.Equal(t, to.MotionLayers, diff.MotionLayers)
+	assert.Nil(t, diff.AutoBlink)
+	require.NotNil(t, diff.TimeScale)
+	assert.Equal(t, 0.5, *diff.TimeScale)
+
+	// Applying the difference to another model in the same state brings it to the newer state
+	other := loadFixture(t)
+	require.NoError(t, other.ApplyStateDiff(diff))
+	applied, err := other.SaveState()
+	require.NoError(t, err)
+	assert.Equal(t, to, applied)
+}
+
+func TestRestoreStateErrors(t *testing.T) {
+	t.Parallel()
+	m := loadFixture(t)
+
+	saved, err := m.SaveState()
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		diff    cubism.StateDiff
+		wantErr error
+	}{
+		{name: "parameter", diff: cubism.StateDiff{Parameters: map[string]float32{"ParamUnknown": 1}}, wantErr: cubism.ErrParameterNotFound},
+		{name: "part", diff: cubism.StateDiff{PartOpacities: map[string]float32{"PartUnknown": 1}}, wantErr: cubism.ErrPartNotFound},
+		{
+			name: "motion",
+			diff: cubism.StateDiff{
+				Parameters: map[string]float32{"ParamAngleX": 12},
+				MotionLayers: []cubism.MotionLayerState{
+					{Name: cubism.BaseMotionLayer, Motions: []cubism.MotionState{{Id: 1, Group: "Unknown"}}},
+				},
+			},
+			wantErr: cubism.ErrMotionGroupNotFound,
+		},
+		{
+			name: "duplicate layer",
+			diff: cubism.StateDiff{
+				MotionLayers: []cubism.MotionLayerState{{Name: cubism.BaseMotionLayer}, {Name: cubism.BaseMotionLayer}},
+			},
+			wantErr: cubism.ErrMotionLayerExists,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.ErrorIs(t, m.ApplyStateDiff(tt.diff), tt.wantErr)
+			// Nothing is changed
+			current, err := m.SaveState()
+			require.NoError(t, err)
+			assert.Equal(t, saved, current)
+		})
+	}
+
+	assert.Error(t, m.RestoreState(cubism.State{}))
+	require.NoError(t, m.Close())
+	_, err = m.SaveState()
+	assert.ErrorIs(t, err, cubism.ErrClosed)
+	assert.ErrorIs(t, m.RestoreState(saved), cubism.ErrClosed)
+}
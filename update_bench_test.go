@@ -0,0 +1,55 @@
+package cubism_test
+
+import (
+	"testing"
+
+	"github.com/aethiopicuschan/cubism-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Not parallel because of testing.AllocsPerRun
+func TestUpdateDoesNotAllocate(t *testing.T) {
+	m := loadFixture(t)
+	_, err := m.PlayMotion("Idle", 0, true)
+	require.NoError(t, err)
+	m.EnableAutoBlink()
+	// Let the first frames allocate the buffers
+	m.Update(1.0 / 60)
+	m.Update(1.0 / 60)
+
+	var s *cubism.Snapshot
+	i := 0
+	allocs := testing.AllocsPerRun(100, func() {
+		m.SetParameterValue("ParamAngleX", float32(i%60-30))
+		m.Update(1.0 / 60)
+		s = m.SnapshotInto(s)
+		i++
+	})
+	assert.Zero(t, allocs)
+	assert.Equal(t, m.GetFrame(), s.GetFrame())
+}
+
+func BenchmarkModelUpdate(b *testing.B) {
+	m := loadFixture(b)
+	_, err := m.PlayMotion("Idle", 0, true)
+	require.NoError(b, err)
+	m.EnableAutoBlink()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		m.Update(1.0 / 60)
+	}
+}
+
+func BenchmarkModelSnapshotInto(b *testing.B) {
+	m := loadFixture(b)
+	m.Update(1.0 / 60)
+	s := m.Snapshot()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		m.Update(1.0 / 60)
+		s = m.SnapshotInto(s)
+	}
+}